@@ -0,0 +1,62 @@
+package feedfetcher
+
+import "strings"
+
+// hostMatches reports whether host is one of domains or a subdomain of one.
+func hostMatches(host string, domains []string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, domain := range domains {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" {
+			continue
+		}
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHost returns ErrBlockedDomain or ErrDomainNotAllowed if config does not
+// permit fetching from host.
+func checkHost(config Config, host string) error {
+	if hostMatches(host, config.BlockedDomains) {
+		return ErrBlockedDomain
+	}
+	if len(config.AllowedDomains) > 0 && !hostMatches(host, config.AllowedDomains) {
+		return ErrDomainNotAllowed
+	}
+	return nil
+}
+
+// WithBlockedDomains returns a new FeedFetcher that refuses to fetch feeds
+// from domains or any of their subdomains, failing with ErrBlockedDomain
+// before any request is made. Redirects into a blocked domain are refused as
+// well. Set Config.DropBlockedLinks to also drop items linking to them.
+func (f *FeedFetcher) WithBlockedDomains(domains []string) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.BlockedDomains = append([]string(nil), domains...)
+	newFetcher.config = newConfig
+
+	// The HTTP client checks redirects against the list
+	newFetcher.parser = newFetcher.newParser()
+
+	return &newFetcher
+}
+
+// WithAllowedDomains returns a new FeedFetcher that only fetches feeds from
+// domains and their subdomains. Any other feed URL, or a redirect leaving the
+// approved set, fails with ErrDomainNotAllowed before a request is sent to
+// it. An empty list allows every domain that is not blocked.
+func (f *FeedFetcher) WithAllowedDomains(domains []string) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.AllowedDomains = append([]string(nil), domains...)
+	newFetcher.config = newConfig
+
+	// The HTTP client checks redirects against the list
+	newFetcher.parser = newFetcher.newParser()
+
+	return &newFetcher
+}
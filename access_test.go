@@ -0,0 +1,98 @@
+package feedfetcher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostMatches(t *testing.T) {
+	blocked := []string{"evil.com", " Malware.Example.org. ", ""}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"evil.com", true},
+		{"feeds.evil.com", true},
+		{"EVIL.com.", true},
+		{"notevil.com", false},
+		{"malware.example.org", true},
+		{"cdn.malware.example.org", true},
+		{"example.org", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, hostMatches(tt.host, blocked))
+		})
+	}
+}
+
+func TestFeedFetcher_WithBlockedDomains(t *testing.T) {
+	called := false
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			called = true
+			return &gofeed.Feed{}, nil
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser).WithBlockedDomains([]string{"evil.com"})
+	fetcher.parser = mockParser
+
+	_, err := fetcher.FetchAndProcess(context.Background(), "https://feeds.evil.com/rss")
+	assert.ErrorIs(t, err, ErrBlockedDomain)
+	assert.False(t, called, "no request is made for a blocked domain")
+}
+
+func TestFeedFetcher_BlockedRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://evil.com/rss", http.StatusFound)
+	}))
+	defer server.Close()
+
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, nil).WithBlockedDomains([]string{"evil.com"})
+	_, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrBlockedDomain)
+}
+
+func TestValidateAndConvertItem_DropBlockedLinks(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	item := &gofeed.Item{Title: "Story", Link: "https://cdn.evil.com/a", PublishedParsed: timePtr(time.Now())}
+
+	config := DefaultConfig
+	config.BlockedDomains = []string{"evil.com"}
+
+	_, err := validateAndConvertItem(config, feedURL, item)
+	require.NoError(t, err, "links are kept unless DropBlockedLinks is set")
+
+	config.DropBlockedLinks = true
+	_, err = validateAndConvertItem(config, feedURL, item)
+	assert.ErrorIs(t, err, ErrBlockedDomain)
+}
+
+func TestFeedFetcher_WithAllowedDomains(t *testing.T) {
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{}, nil
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser).WithAllowedDomains([]string{"example.com"})
+	fetcher.parser = mockParser
+
+	_, err := fetcher.FetchAndProcess(context.Background(), "https://feeds.example.com/rss")
+	assert.NoError(t, err)
+
+	_, err = fetcher.FetchAndProcess(context.Background(), "http://169.254.169.254/latest/meta-data")
+	assert.ErrorIs(t, err, ErrDomainNotAllowed)
+
+	_, err = fetcher.WithBlockedDomains([]string{"feeds.example.com"}).
+		FetchAndProcess(context.Background(), "https://feeds.example.com/rss")
+	assert.ErrorIs(t, err, ErrBlockedDomain, "the blocklist wins over the allowlist")
+}
@@ -0,0 +1,219 @@
+package feedfetcher
+
+import (
+	"context"
+	"sync"
+)
+
+// defaultBatchConcurrency is the number of feeds FetchMany fetches at once
+// when BatchOptions.Concurrency is not set.
+const defaultBatchConcurrency = 8
+
+// BatchOptions controls a FetchMany call.
+type BatchOptions struct {
+	Concurrency int // Feeds fetched at once; 0 uses 8
+
+	// Dedup removes cross-feed duplicates from BatchResult.Merged. Two
+	// items are duplicates when they share a normalized URL, a GUID or a
+	// headline (compared case-insensitively). The first occurrence wins,
+	// in the order of the feed urls passed to FetchMany and then of the
+	// items within each feed. Per-feed results are never deduplicated.
+	Dedup bool
+
+	// Sort orders BatchResult.Merged; SortNone keeps feed order.
+	Sort SortOrder
+
+	// MaxItemsPerFeed caps the items extracted from each feed, in place of
+	// the fetcher's MaxItems; 0 keeps the fetcher's setting and a negative
+	// value removes the cap. MaxTotalItems, if positive, caps
+	// BatchResult.Merged: items are taken in feed order, or in Sort order
+	// when sorting, so with SortNewestFirst the newest items across all
+	// feeds are kept. The cap applies after deduplication, and per-feed
+	// results are never truncated by it.
+	//
+	// In feed order without Dedup, MaxTotalItems also bounds the work:
+	// once the leading feeds have supplied enough items, feeds that have
+	// not started are skipped and those still running are canceled, their
+	// FeedResult failing with ErrBatchLimitReached. Sorting or
+	// deduplicating needs every feed, so all are fetched and only Merged
+	// is cut.
+	MaxItemsPerFeed int
+	MaxTotalItems   int
+
+	// OnItem, if set, receives the items of each feed while it is being
+	// converted, in batches of up to 100 as with FetchOptions.OnItems,
+	// instead of FetchMany collecting them: FeedResult.Items and
+	// BatchResult.Merged stay empty, and Dedup, Sort and MaxTotalItems
+	// have no effect. Items delivered before a feed fails are not taken
+	// back. Calls are serialized, so OnItem need not be safe for
+	// concurrent use, but a slow OnItem holds up the workers.
+	OnItem ItemCallback
+}
+
+// ItemCallback receives an item accepted from the feed at feedURL.
+type ItemCallback func(feedURL string, item *FeedItem)
+
+// FeedResult is the outcome of fetching one feed, by FetchFull or in a
+// FetchMany batch. Meta is nil when the feed could not be downloaded or
+// parsed; Stats cover whatever work was done before an error.
+type FeedResult struct {
+	URL        string
+	Meta       *FeedMeta
+	Items      []*FeedItem
+	Stats      FetchStats
+	ItemErrors []ItemError // Items that were dropped, including those filtered by MaxAge or domain lists
+	Err        error
+}
+
+// FetchFull fetches and processes a feed like FetchAndProcess and returns
+// everything known about it: metadata, items, statistics and per-item errors.
+// The result is never nil; on failure it holds the error that is also
+// returned, along with the statistics gathered so far.
+func (f *FeedFetcher) FetchFull(ctx context.Context, feedURL string) (*FeedResult, error) {
+	result := f.fetchResult(ctx, feedURL, FetchOptions{})
+	return &result, result.Err
+}
+
+// forEachConcurrently calls fn for every index below n, starting them in
+// index order and running up to concurrency calls at once
+// (defaultBatchConcurrency if not positive), and returns once all have
+// finished.
+func forEachConcurrently(n, concurrency int, fn func(i int)) {
+	if concurrency <= 0 {
+		concurrency = defaultBatchConcurrency
+	}
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range n {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchResult fetches a feed and collects its outcome into a FeedResult.
+func (f *FeedFetcher) fetchResult(ctx context.Context, feedURL string, opts FetchOptions) FeedResult {
+	ff, items, err := f.fetch(ctx, feedURL, opts)
+	result := FeedResult{URL: feedURL, Items: items, Err: err}
+	if ff != nil {
+		result.Stats = ff.stats
+		result.ItemErrors = ff.itemErrs
+		if ff.data != nil {
+			result.Meta = f.newFeedMeta(ff)
+		}
+	}
+	return result
+}
+
+// BatchResult holds the outcome of a FetchMany call.
+type BatchResult struct {
+	Feeds  []FeedResult // One entry per feed url, in the order given
+	Merged []*FeedItem  // The items of all feeds, deduplicated, sorted and capped as BatchOptions asks
+}
+
+// FetchMany fetches feedURLs concurrently, each as FetchAndProcess would,
+// and returns the per-feed results along with all items merged, or streams
+// the items to BatchOptions.OnItem. A failed feed does not stop the others;
+// its error is in its FeedResult. Merged items are shared with the per-feed
+// results, not copies.
+func (f *FeedFetcher) FetchMany(ctx context.Context, feedURLs []string, opts BatchOptions) BatchResult {
+	results := make([]FeedResult, len(feedURLs))
+	var mu sync.Mutex // serializes OnItem calls
+
+	// Feeds still running when the cap is reached fail with its cause,
+	// ErrBatchLimitReached, in their results and hooks alike
+	fetchCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var limit *batchLimit
+	if opts.MaxTotalItems > 0 && opts.Sort == SortNone && !opts.Dedup && opts.OnItem == nil {
+		limit = newBatchLimit(len(feedURLs), opts.MaxTotalItems)
+	}
+
+	forEachConcurrently(len(feedURLs), opts.Concurrency, func(i int) {
+		if limit != nil && limit.skip(i) {
+			results[i] = FeedResult{URL: feedURLs[i], Err: newFeedError(feedURLs[i], PhaseDownload, ErrBatchLimitReached)}
+			return
+		}
+		fetchOpts := FetchOptions{MaxItems: opts.MaxItemsPerFeed}
+		if opts.OnItem != nil {
+			fetchOpts.OnItems = func(items []*FeedItem) {
+				mu.Lock()
+				defer mu.Unlock()
+				for _, item := range items {
+					opts.OnItem(feedURLs[i], item)
+				}
+			}
+		}
+		results[i] = f.fetchResult(fetchCtx, feedURLs[i], fetchOpts)
+		if limit != nil && limit.done(i, len(results[i].Items)) {
+			cancel(ErrBatchLimitReached)
+		}
+	})
+	if opts.OnItem != nil {
+		return BatchResult{Feeds: results}
+	}
+
+	merge := MergeOptions{Sort: opts.Sort}
+	if opts.Dedup {
+		merge.DedupBy = DedupByAll
+	}
+	sets := make([][]*FeedItem, len(results))
+	for i, result := range results {
+		sets[i] = result.Items
+	}
+	merged := MergeItemsWithOptions(merge, sets...)
+	if opts.MaxTotalItems > 0 && len(merged) > opts.MaxTotalItems {
+		merged = merged[:opts.MaxTotalItems:opts.MaxTotalItems]
+	}
+	return BatchResult{Feeds: results, Merged: merged}
+}
+
+// batchLimit finds the point in a batch after which no feed can contribute
+// to a MaxTotalItems cap taken in feed order: the first feed at which the
+// feeds up to and including it, all finished, have supplied enough items.
+type batchLimit struct {
+	mu     sync.Mutex
+	max    int
+	counts []int // Accepted items per feed; -1 until the feed has finished
+	next   int   // First feed that has not finished
+	total  int   // Items of the feeds before next
+	cutoff int   // Last feed needed, or len(counts) while unknown
+}
+
+func newBatchLimit(feeds, maxItems int) *batchLimit {
+	counts := make([]int, feeds)
+	for i := range counts {
+		counts[i] = -1
+	}
+	return &batchLimit{max: maxItems, counts: counts, cutoff: feeds}
+}
+
+// skip reports whether feed i is past the cutoff.
+func (l *batchLimit) skip(i int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return i > l.cutoff
+}
+
+// done records that feed i finished with n items and reports whether that
+// settled the cutoff.
+func (l *batchLimit) done(i, n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.counts[i] = n
+	for l.cutoff == len(l.counts) && l.next < len(l.counts) && l.counts[l.next] >= 0 {
+		l.total += l.counts[l.next]
+		if l.total >= l.max {
+			l.cutoff = l.next
+			return true
+		}
+		l.next++
+	}
+	return false
+}
@@ -0,0 +1,298 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// feedServer serves an RSS feed of the given items, each a title, link and
+// guid triple.
+func feedServer(t *testing.T, items ...[3]string) *httptest.Server {
+	t.Helper()
+	pubDate := time.Now().Add(-time.Hour).Format(time.RFC1123Z)
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0"?><rss version="2.0"><channel><title>Batch</title>`)
+	for _, item := range items {
+		fmt.Fprintf(&b, `<item><title>%s</title><link>%s</link><guid>%s</guid><pubDate>%s</pubDate></item>`,
+			item[0], item[1], item[2], pubDate)
+	}
+	b.WriteString(`</channel></rss>`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, b.String())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFeedFetcher_FetchMany(t *testing.T) {
+	wire := feedServer(t,
+		[3]string{"Wire story", "https://news.example.com/wire/1", "wire-1"},
+		[3]string{"Local story", "https://news.example.com/local/2", "local-2"},
+	)
+	outlet := feedServer(t,
+		[3]string{"Wire Story (syndicated)", "https://www.news.example.com/wire/1/#top", "outlet-9"},
+		[3]string{"Same guid", "https://outlet.example.com/x", "local-2"},
+		[3]string{"WIRE STORY", "https://outlet.example.com/y", "outlet-10"},
+		[3]string{"Exclusive", "https://outlet.example.com/z", "outlet-11"},
+	)
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	urls := []string{wire.URL, broken.URL, outlet.URL}
+	fetcher := NewDefaultFeedFetcher()
+
+	result := fetcher.FetchMany(context.Background(), urls, BatchOptions{Concurrency: 2})
+	require.Len(t, result.Feeds, 3)
+	for i, feed := range result.Feeds {
+		assert.Equal(t, urls[i], feed.URL)
+	}
+	assert.NoError(t, result.Feeds[0].Err)
+	assert.Error(t, result.Feeds[1].Err)
+	assert.NoError(t, result.Feeds[2].Err)
+	assert.Len(t, result.Feeds[0].Items, 2)
+	assert.Len(t, result.Feeds[2].Items, 4)
+	assert.Len(t, result.Merged, 6, "no dedup unless asked")
+
+	result = fetcher.FetchMany(context.Background(), urls, BatchOptions{Dedup: true})
+	var headlines []string
+	for _, item := range result.Merged {
+		headlines = append(headlines, item.Headline)
+	}
+	assert.Equal(t, []string{"Wire story", "Local story", "Exclusive"}, headlines, "first by feed order wins")
+	assert.Len(t, result.Feeds[2].Items, 4, "per-feed results are not deduplicated")
+}
+
+func TestFeedFetcher_FetchMany_Caps(t *testing.T) {
+	now := time.Now()
+	// Feed "a" is large and old, feed "b" small and recent
+	feeds := map[string][]*gofeed.Item{}
+	for i := range 5 {
+		feeds["https://a.example.com/feed"] = append(feeds["https://a.example.com/feed"],
+			&gofeed.Item{Title: fmt.Sprintf("a%d", i), Link: fmt.Sprintf("/a%d", i), PublishedParsed: timePtr(now.Add(-time.Duration(10+i) * time.Hour))})
+	}
+	for i := range 2 {
+		feeds["https://b.example.com/feed"] = append(feeds["https://b.example.com/feed"],
+			&gofeed.Item{Title: fmt.Sprintf("b%d", i), Link: fmt.Sprintf("/b%d", i), PublishedParsed: timePtr(now.Add(-time.Duration(i) * time.Hour))})
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: feeds[url]}, nil
+		},
+	})
+	urls := []string{"https://a.example.com/feed", "https://b.example.com/feed"}
+
+	headlines := func(items []*FeedItem) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Headline)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name string
+		opts BatchOptions
+		want []string
+	}{
+		{"no caps", BatchOptions{}, []string{"a0", "a1", "a2", "a3", "a4", "b0", "b1"}},
+		{"per feed", BatchOptions{MaxItemsPerFeed: 2}, []string{"a0", "a1", "b0", "b1"}},
+		{"total in feed order", BatchOptions{MaxTotalItems: 3}, []string{"a0", "a1", "a2"}},
+		{"total after sort", BatchOptions{MaxTotalItems: 3, Sort: SortNewestFirst}, []string{"b0", "b1", "a0"}},
+		{"both", BatchOptions{MaxItemsPerFeed: 1, MaxTotalItems: 3, Sort: SortOldestFirst}, []string{"a0", "b0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := fetcher.FetchMany(context.Background(), urls, tt.opts)
+			assert.Equal(t, tt.want, headlines(result.Merged))
+			if tt.opts.MaxItemsPerFeed == 0 {
+				assert.Len(t, result.Feeds[0].Items, 5, "total cap leaves per-feed results alone")
+			}
+		})
+	}
+}
+
+func TestFeedFetcher_FetchMany_MaxTotalItemsStopsEarly(t *testing.T) {
+	now := time.Now()
+	var mu sync.Mutex
+	var fetched []string
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			mu.Lock()
+			fetched = append(fetched, url)
+			mu.Unlock()
+			var items []*gofeed.Item
+			for i := range 2 {
+				items = append(items, &gofeed.Item{Title: fmt.Sprintf("%s %d", url, i), Link: fmt.Sprintf("/%d", i), PublishedParsed: &now})
+			}
+			return &gofeed.Feed{Items: items}, nil
+		},
+	})
+	urls := []string{"https://a.example.com/feed", "https://b.example.com/feed", "https://c.example.com/feed", "https://d.example.com/feed"}
+
+	result := fetcher.FetchMany(context.Background(), urls, BatchOptions{Concurrency: 1, MaxTotalItems: 3})
+	assert.Len(t, result.Merged, 3)
+	assert.Equal(t, urls[:2], fetched, "feeds after the cap are not fetched")
+	assert.NoError(t, result.Feeds[1].Err)
+	for _, feed := range result.Feeds[2:] {
+		assert.ErrorIs(t, feed.Err, ErrBatchLimitReached)
+		assert.Empty(t, feed.Items)
+	}
+
+	fetched = nil
+	result = fetcher.FetchMany(context.Background(), urls, BatchOptions{Concurrency: 1, MaxTotalItems: 3, Dedup: true})
+	assert.Len(t, result.Merged, 3)
+	assert.Len(t, fetched, 4, "deduplication needs every feed")
+}
+
+func TestFeedFetcher_FetchMany_MaxTotalItemsCancelsRunning(t *testing.T) {
+	now := time.Now()
+	rec := &recorder{}
+	var completed sync.Map
+	slowStarted := make(chan struct{})
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			if strings.Contains(url, "slow") {
+				close(slowStarted)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			}
+			<-slowStarted // reach the cap while the slow feed is running
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{Title: "One", Link: "/1", PublishedParsed: &now},
+				{Title: "Two", Link: "/2", PublishedParsed: &now},
+			}}, nil
+		},
+	}).WithMetricsRecorder(rec).WithOnFetchComplete(func(feedURL string, stats FetchStats, err error) {
+		completed.Store(feedURL, err)
+	})
+	urls := []string{"https://fast.example.com/feed", "https://slow.example.com/feed"}
+
+	result := fetcher.FetchMany(context.Background(), urls, BatchOptions{Concurrency: 2, MaxTotalItems: 2})
+	assert.Len(t, result.Merged, 2)
+	assert.ErrorIs(t, result.Feeds[1].Err, ErrBatchLimitReached)
+
+	hookErr, ok := completed.Load(urls[1])
+	require.True(t, ok)
+	assert.ErrorIs(t, hookErr.(error), ErrBatchLimitReached)
+	assert.NotErrorIs(t, hookErr.(error), context.Canceled)
+	require.Len(t, rec.fetches, 2)
+	for _, fetch := range rec.fetches {
+		if fetch.url == urls[1] {
+			assert.ErrorIs(t, fetch.err, ErrBatchLimitReached)
+		}
+	}
+}
+
+func TestFeedFetcher_FetchMany_OnItem(t *testing.T) {
+	now := time.Now()
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			if strings.Contains(url, "broken") {
+				return nil, errors.New("boom")
+			}
+			var items []*gofeed.Item
+			for i := range 3 {
+				items = append(items, &gofeed.Item{Title: fmt.Sprintf("%s %d", url, i), Link: fmt.Sprintf("/%d", i), PublishedParsed: &now})
+			}
+			return &gofeed.Feed{Items: items}, nil
+		},
+	})
+	urls := []string{"https://a.example.com/feed", "https://broken.example.com/feed", "https://c.example.com/feed"}
+
+	counts := map[string]int{} // unsynchronized: calls must be serialized
+	result := fetcher.FetchMany(context.Background(), urls, BatchOptions{
+		Concurrency: 3,
+		Dedup:       true,
+		OnItem: func(feedURL string, item *FeedItem) {
+			assert.Equal(t, feedURL, item.FeedURL)
+			counts[feedURL]++
+		},
+	})
+
+	assert.Equal(t, map[string]int{urls[0]: 3, urls[2]: 3}, counts)
+	assert.Empty(t, result.Merged)
+	require.Len(t, result.Feeds, 3)
+	for _, feed := range result.Feeds {
+		assert.Empty(t, feed.Items)
+	}
+	assert.NoError(t, result.Feeds[0].Err)
+	assert.Error(t, result.Feeds[1].Err, "errors are still collected per feed")
+	assert.Equal(t, 3, result.Feeds[2].Stats.ItemsAccepted)
+}
+
+func TestFeedFetcher_FetchMany_OnItemStreams(t *testing.T) {
+	now := time.Now()
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			var items []*gofeed.Item
+			for i := range 150 {
+				items = append(items, &gofeed.Item{Title: fmt.Sprint(i), Link: fmt.Sprintf("/%d", i), PublishedParsed: &now})
+			}
+			items = append(items, &gofeed.Item{Title: "Broken", Link: "/broken", Published: "someday"})
+			return &gofeed.Feed{Items: items}, nil
+		},
+	})
+
+	var count int
+	result := fetcher.FetchMany(context.Background(), []string{"https://example.com/feed"}, BatchOptions{
+		OnItem: func(feedURL string, item *FeedItem) { count++ },
+	})
+	assert.Equal(t, 100, count, "the first batch is delivered before the feed fails")
+	assert.ErrorIs(t, result.Feeds[0].Err, ErrFeedPublicationDateFormat)
+}
+
+func TestFeedFetcher_FetchFull(t *testing.T) {
+	now := time.Now().UTC()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Full</title>
+			<item><title>Fresh</title><link>/fresh</link><pubDate>%s</pubDate></item>
+			<item><title>Stale</title><link>/stale</link><pubDate>%s</pubDate></item>
+			<item><title></title><link>/untitled</link><pubDate>%s</pubDate></item>
+			</channel></rss>`,
+			now.Format(time.RFC1123Z), now.Add(-72*time.Hour).Format(time.RFC1123Z), now.Format(time.RFC1123Z))
+	}))
+	defer server.Close()
+	fetcher := NewDefaultFeedFetcher()
+
+	result, err := fetcher.FetchFull(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, server.URL, result.URL)
+	require.NotNil(t, result.Meta)
+	assert.Equal(t, "Full", result.Meta.Title)
+	assert.Equal(t, "application/rss+xml", result.Meta.ContentType)
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Fresh", result.Items[0].Headline)
+	assert.Equal(t, 3, result.Stats.ItemsFound)
+	assert.Equal(t, 1, result.Stats.ItemsAccepted)
+	require.Len(t, result.ItemErrors, 2)
+	assert.Equal(t, 1, result.ItemErrors[0].Index)
+	assert.Equal(t, 2, result.ItemErrors[1].Index)
+	assert.NoError(t, result.Err)
+
+	result, err = fetcher.FetchFull(context.Background(), server.URL+"/missing")
+	require.Error(t, err)
+	require.NotNil(t, result)
+	assert.Equal(t, err, result.Err)
+	assert.Nil(t, result.Meta)
+	assert.Empty(t, result.Items)
+	assert.NotZero(t, result.Stats.Duration)
+}
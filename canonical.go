@@ -0,0 +1,61 @@
+package feedfetcher
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/reddot-watch/feedfetcher/internal/validation"
+)
+
+// canonicalURL returns the target of the first <link rel="canonical">
+// element found in the HTML fragments, resolved against the item url. Only
+// absolute http and https results are returned; otherwise it is empty.
+func canonicalURL(itemURL string, fragments ...string) string {
+	base, err := url.Parse(itemURL)
+	if err != nil {
+		return ""
+	}
+	for _, fragment := range fragments {
+		if href := findCanonicalLink(fragment); href != "" {
+			if resolved := resolveOptionalURL(base, base, href); validation.IsAbsoluteHTTPURL(resolved) {
+				return resolved
+			}
+		}
+	}
+	return ""
+}
+
+// findCanonicalLink returns the raw href of the first link element whose rel
+// includes "canonical".
+func findCanonicalLink(content string) string {
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "link" || !hasAttr {
+				continue
+			}
+			var rel, href string
+			for more := true; more; {
+				var key, val []byte
+				key, val, more = z.TagAttr()
+				switch string(key) {
+				case "rel":
+					rel = string(val)
+				case "href":
+					href = string(val)
+				}
+			}
+			for _, r := range strings.Fields(rel) {
+				if strings.EqualFold(r, "canonical") && href != "" {
+					return href
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package feedfetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	itemURL := "https://mirror.example.net/2024/story.html"
+
+	tests := []struct {
+		name      string
+		fragments []string
+		want      string
+	}{
+		{"absolute", []string{`<link rel="canonical" href="https://news.example.com/story"><p>Body</p>`}, "https://news.example.com/story"},
+		{"relative to item", []string{`<link href="../original" rel="canonical"/>`}, "https://mirror.example.net/original"},
+		{"rel list, case-insensitive", []string{`<LINK REL="Alternate CANONICAL" HREF="/orig">`}, "https://mirror.example.net/orig"},
+		{"first wins", []string{`<link rel="canonical" href="/a"><link rel="canonical" href="/b">`}, "https://mirror.example.net/a"},
+		{"second fragment", []string{`<p>No link</p>`, `<link rel="canonical" href="/from-summary">`}, "https://mirror.example.net/from-summary"},
+		{"other rel", []string{`<link rel="stylesheet" href="/canonical.css">`}, ""},
+		{"anchor ignored", []string{`<a rel="canonical" href="/a">canonical</a>`}, ""},
+		{"empty href", []string{`<link rel="canonical" href="">`}, ""},
+		{"non-http scheme", []string{`<link rel="canonical" href="javascript:alert(1)">`}, ""},
+		{"text mention only", []string{`the canonical version`}, ""},
+		{"no fragments", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, canonicalURL(itemURL, tt.fragments...))
+		})
+	}
+}
+
+func TestConvertItems_ExtractCanonicalURL(t *testing.T) {
+	feedURL := mustParseURL(t, "https://mirror.example.net/feed")
+	items := []*gofeed.Item{{
+		Title:           "Syndicated",
+		Link:            "/copy?utm_source=feed",
+		Content:         `<link rel="canonical" href="https://news.example.com/story?utm_source=x"><p>Body</p>`,
+		PublishedParsed: timePtr(time.Now()),
+	}}
+
+	got, _ := ConvertItems(feedURL, items, DefaultConfig)
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].CanonicalURL, "off by default")
+
+	config := DefaultConfig
+	config.ExtractCanonicalURL = true
+	config.URLNormalizer = StripTrackingParams
+	got, _ = ConvertItems(feedURL, items, config)
+	require.Len(t, got, 1)
+	assert.Equal(t, "https://news.example.com/story", got[0].CanonicalURL)
+	assert.Equal(t, "https://mirror.example.net/copy", got[0].URL)
+}
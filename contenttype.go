@@ -0,0 +1,49 @@
+package feedfetcher
+
+import (
+	"mime"
+	"strings"
+)
+
+// feedContentTypes are the media types feeds are expected to be served as.
+// Types ending in +xml are accepted as well.
+var feedContentTypes = map[string]bool{
+	"application/rss+xml":   true,
+	"application/atom+xml":  true,
+	"application/rdf+xml":   true,
+	"application/xml":       true,
+	"text/xml":              true,
+	"application/feed+json": true,
+	"application/json":      true,
+}
+
+// mediaType returns the lowercase media type of a Content-Type header value,
+// without parameters.
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Malformed parameters; the type before them is still useful
+		mt, _, _ = strings.Cut(contentType, ";")
+		mt = strings.ToLower(strings.TrimSpace(mt))
+	}
+	return mt
+}
+
+// isFeedContentType reports whether a media type is one feeds are served as.
+func isFeedContentType(mt string) bool {
+	return feedContentTypes[mt] || strings.HasSuffix(mt, "+xml")
+}
+
+// feedTypeFromContentType returns the feed type a media type implies, or an
+// empty string for generic types such as application/xml.
+func feedTypeFromContentType(mt string) string {
+	switch mt {
+	case "application/rss+xml", "application/rdf+xml":
+		return "rss"
+	case "application/atom+xml":
+		return "atom"
+	case "application/feed+json":
+		return "json"
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package feedfetcher
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddot-watch/feedfetcher/feedfetchertest"
+)
+
+func TestMediaType(t *testing.T) {
+	assert.Equal(t, "application/rss+xml", mediaType("application/RSS+xml; charset=UTF-8"))
+	assert.Equal(t, "text/xml", mediaType("text/xml; charset"))
+	assert.Equal(t, "", mediaType(""))
+}
+
+func TestFeedFetcher_StrictContentType(t *testing.T) {
+	body := rssDocument(time.Now(), "Typed")
+
+	tests := []struct {
+		name        string
+		contentType string
+		wantErr     bool
+	}{
+		{"rss", "application/rss+xml; charset=utf-8", false},
+		{"atom", "application/atom+xml", false},
+		{"generic xml", "text/xml", false},
+		{"vendor xml", "application/vnd.example+xml", false},
+		{"plain text", "text/plain; charset=utf-8", true},
+		{"octet stream", "application/octet-stream", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			header.Set("Content-Type", tt.contentType)
+			server := feedfetchertest.NewServer(feedfetchertest.Response{Header: header, Body: body})
+			defer server.Close()
+
+			lenient := NewDefaultFeedFetcher()
+			meta, items, err := lenient.FetchFeed(context.Background(), server.URL)
+			require.NoError(t, err, "the default still parses the feed")
+			assert.Len(t, items, 1)
+			assert.Equal(t, mediaType(tt.contentType), meta.ContentType)
+
+			config := DefaultConfig
+			config.StrictContentType = true
+			strict := NewFeedFetcher(config)
+			_, err = strict.FetchAndProcess(context.Background(), server.URL)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrUnexpectedContentType)
+			var feedErr *FeedError
+			require.ErrorAs(t, err, &feedErr)
+			assert.Equal(t, PhaseDownload, feedErr.Phase)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package feedfetcher
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/reddot-watch/feedfetcher/internal/validation"
+)
+
+// ItemError records why a feed item was dropped by ConvertItems.
+type ItemError struct {
+	Index int    // Position of the item in the input slice
+	Link  string // The item's link as it appears in the feed
+	Err   error
+}
+
+func (e ItemError) Error() string {
+	return fmt.Sprintf("item %d (%s): %v", e.Index, e.Link, e.Err)
+}
+
+func (e ItemError) Unwrap() error {
+	return e.Err
+}
+
+// ConvertItems validates and converts items that were parsed elsewhere, for
+// example by a separate gofeed.Parser, exactly as FetchAndProcess would:
+// relative links are resolved against feedURL and cfg's item and scan limits,
+// publication date bounds, headline rules and deduplication all apply. Items
+// that fail validation are reported in the returned errors; nil items and
+// duplicate headlines are skipped silently. Items sharing a GUID are kept and
+// flagged with DuplicateGUID. The xml:base of RSS items is only known for
+// feeds parsed by a FeedFetcher; a plain gofeed.Parser drops it. Nor is the
+// feed's site link known here, so DropSelfReferentialLinks only drops items
+// linking to feedURL.
+//
+// Conversion stops at the first item whose publication date is in a format
+// that cannot be parsed, because the rest of the feed almost always uses the
+// same format. Its error, wrapping the validation error, is then the last
+// entry.
+func ConvertItems(feedURL *url.URL, items []*gofeed.Item, cfg Config) ([]*FeedItem, []ItemError) {
+	return convertItems(feedURL, "", items, cfg)
+}
+
+// convertItems is ConvertItems for a feed whose site link, resolved, is
+// siteURL; it may be empty. DropSelfReferentialLinks compares with it too.
+func convertItems(feedURL *url.URL, siteURL string, items []*gofeed.Item, cfg Config) ([]*FeedItem, []ItemError) {
+	capacity := len(items)
+	if cfg.MaxItems > 0 {
+		capacity = min(capacity, cfg.MaxItems)
+	}
+	result := make([]*FeedItem, 0, capacity)
+	var itemErrs []ItemError
+
+	convertEach(feedURL, siteURL, items, cfg, func(item *FeedItem, itemErr *ItemError) bool {
+		if itemErr != nil {
+			itemErrs = append(itemErrs, *itemErr)
+		} else {
+			result = append(result, item)
+		}
+		return true
+	})
+	markDuplicateGUIDs(result)
+
+	return result, itemErrs
+}
+
+// convertEach does the work of convertItems, one item at a time: yield
+// receives each converted item, or the error of each dropped one, in feed
+// order, and conversion stops early when it returns false. It also stops
+// once MaxItems items have been accepted or MaxScanItems items looked at,
+// whichever comes first. Duplicate GUIDs are left for the caller to mark.
+func convertEach(feedURL *url.URL, siteURL string, items []*gofeed.Item, cfg Config, yield func(*FeedItem, *ItemError) bool) {
+	// Determine how many items to look at
+	itemCount := len(items)
+	if cfg.MaxScanItems > 0 && cfg.MaxScanItems < itemCount {
+		itemCount = cfg.MaxScanItems
+	}
+
+	if cfg.FutureSkewWindow > 0 {
+		cfg.FutureDriftTolerance += validation.FeedClockSkew(items[:itemCount], time.Now(), cfg.FutureSkewWindow)
+	}
+
+	var seenHeadlines map[string]struct{}
+	if cfg.DedupHeadlines {
+		seenHeadlines = make(map[string]struct{}, itemCount)
+	}
+
+	accepted := 0
+	for i := 0; i < itemCount && (cfg.MaxItems <= 0 || accepted < cfg.MaxItems); i++ {
+		item := items[i]
+		if item == nil {
+			continue
+		}
+
+		parsed, err := validateAndConvertItem(cfg, feedURL, item)
+		if err == nil && cfg.DropSelfReferentialLinks && isSelfReference(parsed.URL, feedURL.String(), siteURL) {
+			err = ErrSelfReferentialURL
+		}
+		if err != nil {
+			if !yield(nil, &ItemError{Index: i, Link: item.Link, Err: err}) ||
+				errors.Is(err, ErrFeedPublicationDateFormat) {
+				// Do not process other items as they will all have the same error
+				return
+			}
+			// Continue processing other items
+			continue
+		}
+		parsed.FeedIndex = i
+
+		if seenHeadlines != nil {
+			// Headlines are already whitespace-normalized; keep the first occurrence
+			key := strings.ToLower(parsed.Headline)
+			if _, seen := seenHeadlines[key]; seen {
+				continue
+			}
+			seenHeadlines[key] = struct{}{}
+		}
+
+		accepted++
+		if !yield(parsed, nil) {
+			return
+		}
+	}
+}
+
+// markDuplicateGUIDs sets DuplicateGUID on every item whose GUID is shared
+// with another item, including the first one, since none of them can be
+// keyed on it.
+func markDuplicateGUIDs(items []*FeedItem) {
+	counts := make(map[string]int, len(items))
+	for _, item := range items {
+		if item.GUID != "" {
+			counts[item.GUID]++
+		}
+	}
+	for _, item := range items {
+		item.DuplicateGUID = counts[item.GUID] > 1
+	}
+}
+
+// isFiltered reports whether an item was dropped by a filtering setting,
+// such as MaxAge, rather than for being invalid.
+func isFiltered(err error) bool {
+	return errors.Is(err, ErrPublicationTooOld) || errors.Is(err, ErrBlockedDomain) ||
+		errors.Is(err, ErrSelfReferentialURL) || errors.Is(err, ErrContentTooShort) ||
+		errors.Is(err, ErrAfterMaxPublishDate) || errors.Is(err, ErrCrossDomainLink) ||
+		errors.Is(err, ErrURLTooLong)
+}
+
+// isSelfReference reports whether itemURL points at the feed itself or at
+// the site's home page, compared the way MergeItems compares URLs.
+func isSelfReference(itemURL, feedURL, siteURL string) bool {
+	key := dedupURL(itemURL)
+	return key == dedupURL(feedURL) || (siteURL != "" && key == dedupURL(siteURL))
+}
@@ -0,0 +1,474 @@
+package feedfetcher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertItems(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "First", Link: "/a", PublishedParsed: timePtr(now)},
+		nil,
+		{Title: "", Link: "/b", PublishedParsed: timePtr(now)},
+		{Title: "Old", Link: "/c", PublishedParsed: timePtr(now.Add(-48 * time.Hour))},
+		{Title: "Last", Link: "/d", PublishedParsed: timePtr(now)},
+	}
+
+	got, itemErrs := ConvertItems(feedURL, items, DefaultConfig)
+	require.Len(t, got, 2)
+	assert.Equal(t, "https://example.com/a", got[0].URL)
+	assert.Equal(t, "https://example.com/d", got[1].URL)
+	assert.Equal(t, 0, got[0].FeedIndex)
+	assert.Equal(t, 4, got[1].FeedIndex, "raw position, counting dropped items")
+
+	require.Len(t, itemErrs, 2)
+	assert.Equal(t, 2, itemErrs[0].Index)
+	assert.ErrorIs(t, itemErrs[0], ErrEmptyHeadline)
+	assert.Equal(t, 3, itemErrs[1].Index)
+	assert.Equal(t, "/c", itemErrs[1].Link)
+	assert.ErrorIs(t, itemErrs[1], ErrPublicationTooOld)
+}
+
+func TestConvertItems_MaxScanItems(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "", Link: "/a", PublishedParsed: timePtr(now)},
+		{Title: "Second", Link: "/b", PublishedParsed: timePtr(now)},
+		{Title: "Third", Link: "/c", PublishedParsed: timePtr(now)},
+		{Title: "Fourth", Link: "/d", PublishedParsed: timePtr(now)},
+	}
+
+	tests := []struct {
+		name     string
+		maxItems int
+		maxScan  int
+		want     int
+	}{
+		{"scan all", 0, 0, 3},
+		{"scan cap", 0, 2, 1},
+		{"scan cap below item cap", 3, 2, 1},
+		{"item cap counts accepted items", 2, 0, 2},
+		{"item cap below scan cap", 2, 3, 2},
+		{"item cap reached before scan cap", 1, 3, 1},
+		{"scan cap beyond feed", 0, 10, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.MaxItems = tt.maxItems
+			config.MaxScanItems = tt.maxScan
+			got, _ := ConvertItems(feedURL, items, config)
+			assert.Len(t, got, tt.want)
+		})
+	}
+}
+
+func TestConvertItems_KeepOldItems(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "New", Link: "/a", PublishedParsed: timePtr(now)},
+		{Title: "Old", Link: "/b", PublishedParsed: timePtr(now.Add(-48 * time.Hour))},
+		{Title: "Ancient", Link: "/c", PublishedParsed: timePtr(time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC))},
+		{Title: "Future", Link: "/d", PublishedParsed: timePtr(now.Add(48 * time.Hour))},
+	}
+	config := DefaultConfig
+	config.KeepOldItems = true
+	config.MinPublishDate = time.Date(2010, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	got, itemErrs := ConvertItems(feedURL, items, config)
+	require.Len(t, got, 3)
+	assert.False(t, got[0].Archived)
+	assert.True(t, got[1].Archived, "beyond MaxAge")
+	assert.True(t, got[1].PublishedAt.Equal(*items[1].PublishedParsed))
+	assert.True(t, got[2].Archived, "before MinPublishDate")
+	require.Len(t, itemErrs, 1, "future dates are still rejected")
+	assert.ErrorIs(t, itemErrs[0], ErrFuturePublication)
+
+	config.KeepOldItems = false
+	got, _ = ConvertItems(feedURL, items, config)
+	assert.Len(t, got, 1)
+}
+
+func TestConvertItems_FutureSkewWindow(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	now := time.Now()
+	item := func(title string, offset time.Duration) *gofeed.Item {
+		return &gofeed.Item{Title: title, Link: "/" + title, PublishedParsed: timePtr(now.Add(offset))}
+	}
+	config := DefaultConfig
+	config.FutureDriftTolerance = 5 * time.Minute
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		items  []*gofeed.Item
+		want   int
+	}{
+		{
+			name:  "fixed tolerance by default",
+			items: []*gofeed.Item{item("a", 20*time.Minute), item("b", 25*time.Minute), item("c", -time.Hour)},
+			want:  1,
+		},
+		{
+			name:   "skew confirmed by other items",
+			window: time.Hour,
+			items:  []*gofeed.Item{item("a", 20*time.Minute), item("b", 25*time.Minute), item("c", -time.Hour)},
+			want:   3,
+		},
+		{
+			name:   "single future item is not confirmed",
+			window: time.Hour,
+			items:  []*gofeed.Item{item("a", 20*time.Minute), item("b", -time.Hour), item("c", -2*time.Hour)},
+			want:   2,
+		},
+		{
+			name:   "dates beyond the window are not evidence",
+			window: time.Hour,
+			items:  []*gofeed.Item{item("a", 20*time.Minute), item("b", 48*time.Hour), item("c", -time.Hour)},
+			want:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.FutureSkewWindow = tt.window
+			got, _ := ConvertItems(feedURL, tt.items, config)
+			assert.Len(t, got, tt.want)
+		})
+	}
+}
+
+func TestFeedFetcher_WithDateParserDisabled(t *testing.T) {
+	now := time.Now()
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{Title: "Parsed", Link: "/a", PublishedParsed: timePtr(now)},
+				{Title: "Unparsed", Link: "/b", Published: now.Format(time.RFC1123Z)},
+				{Title: "Broken", Link: "/c", Published: "not a date"},
+			}}, nil
+		},
+	})
+
+	items, err := fetcher.FetchAndProcess(context.Background(), "https://example.com/feed")
+	assert.ErrorIs(t, err, ErrFeedPublicationDateFormat, "the dateparser rejects the broken date")
+	assert.Empty(t, items)
+
+	result, err := fetcher.WithDateParserDisabled().FetchFull(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	items, itemErrs := result.Items, result.ItemErrors
+	require.Len(t, items, 1)
+	assert.Equal(t, "Parsed", items[0].Headline)
+	require.Len(t, itemErrs, 2, "conversion does not stop at the broken date")
+	for _, itemErr := range itemErrs {
+		assert.ErrorIs(t, itemErr, ErrMissingPublishDate)
+	}
+}
+
+func TestConvertItems_StopsOnDateFormat(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	items := []*gofeed.Item{
+		{Title: "First", Link: "/a", PublishedParsed: timePtr(time.Now())},
+		{Title: "Broken", Link: "/b", Published: "not a date"},
+		{Title: "Never seen", Link: "/c", Published: "not a date"},
+	}
+
+	got, itemErrs := ConvertItems(feedURL, items, DefaultConfig)
+	assert.Len(t, got, 1)
+	require.Len(t, itemErrs, 1)
+	assert.Equal(t, 1, itemErrs[0].Index)
+	assert.ErrorIs(t, itemErrs[0], ErrFeedPublicationDateFormat)
+}
+
+func TestConvertItems_MaxURLLength(t *testing.T) {
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "Short", Link: "/a", PublishedParsed: timePtr(now)},
+		{Title: "Long", Link: "/" + strings.Repeat("x", 100), PublishedParsed: timePtr(now)},
+	}
+	config := DefaultConfig
+	config.MaxURLLength = 64
+
+	got, itemErrs := ConvertItems(mustParseURL(t, "https://example.com/feed"), items, config)
+	require.Len(t, got, 1)
+	assert.Equal(t, "https://example.com/a", got[0].URL)
+	require.Len(t, itemErrs, 1)
+	assert.ErrorIs(t, itemErrs[0], ErrURLTooLong)
+	assert.Equal(t, 1, itemErrs[0].Index)
+}
+
+func TestConvertItems_PublishDateBounds(t *testing.T) {
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "Early", Link: "/a", PublishedParsed: timePtr(now.Add(-72 * time.Hour))},
+		{Title: "Inside", Link: "/b", PublishedParsed: timePtr(now.Add(-36 * time.Hour))},
+		{Title: "Late", Link: "/c", PublishedParsed: timePtr(now.Add(-time.Hour))},
+	}
+	config := DefaultConfig
+	config.MaxAge = 0
+	config.MinPublishDate = now.Add(-48 * time.Hour)
+	config.MaxPublishDate = now.Add(-24 * time.Hour)
+
+	got, itemErrs := ConvertItems(mustParseURL(t, "https://example.com/feed"), items, config)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Inside", got[0].Headline)
+	require.Len(t, itemErrs, 2)
+	assert.ErrorIs(t, itemErrs[0], ErrPublicationTooOld)
+	assert.ErrorIs(t, itemErrs[1], ErrFuturePublication)
+}
+
+func TestFeedFetcher_WithStrictValidation(t *testing.T) {
+	now := time.Now()
+	feedItems := []*gofeed.Item{
+		{Title: "Good", Link: "/a", PublishedParsed: timePtr(now)},
+		{Title: "Old", Link: "/old", PublishedParsed: timePtr(now.Add(-48 * time.Hour))},
+	}
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: feedItems}, nil
+		},
+	}
+	config := DefaultConfig
+	config.MaxAge = 24 * time.Hour
+	fetcher := NewFeedFetcherWithParser(config, mockParser)
+
+	items, err := fetcher.WithStrictValidation().FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err, "too old items are filtered, not invalid")
+	assert.Len(t, items, 1)
+
+	feedItems = append(feedItems, &gofeed.Item{Title: "", Link: "/untitled", PublishedParsed: timePtr(now)})
+
+	items, err = fetcher.FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err, "lenient by default")
+	assert.Len(t, items, 1)
+
+	_, err = fetcher.WithStrictValidation().FetchAndProcess(context.Background(), "https://example.com/feed")
+	assert.ErrorIs(t, err, ErrEmptyHeadline)
+	var itemErr ItemError
+	require.ErrorAs(t, err, &itemErr)
+	assert.Equal(t, 2, itemErr.Index)
+	var feedErr *FeedError
+	require.ErrorAs(t, err, &feedErr)
+	assert.Equal(t, PhaseValidate, feedErr.Phase)
+}
+
+func TestFeedFetcher_StrictValidationFilters(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		item    *gofeed.Item
+		setup   func(*Config)
+		wantErr error
+	}{
+		{
+			"MaxPublishDate",
+			&gofeed.Item{Title: "Later", Link: "/later", PublishedParsed: timePtr(now)},
+			func(c *Config) { c.MaxPublishDate = now.Add(-time.Hour) },
+			ErrAfterMaxPublishDate,
+		},
+		{
+			"RejectCrossDomainLinks",
+			&gofeed.Item{Title: "Elsewhere", Link: "https://other.com/a", PublishedParsed: timePtr(now)},
+			func(c *Config) { c.RejectCrossDomainLinks = true },
+			ErrCrossDomainLink,
+		},
+		{
+			"MaxURLLength",
+			&gofeed.Item{Title: "Long", Link: "/" + strings.Repeat("a", 100), PublishedParsed: timePtr(now)},
+			func(c *Config) { c.MaxURLLength = 64 },
+			ErrURLTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := &MockFeedParser{
+				MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+					return &gofeed.Feed{Items: []*gofeed.Item{
+						{Title: "Good", Link: "/a", PublishedParsed: timePtr(now.Add(-2 * time.Hour))},
+						tt.item,
+					}}, nil
+				},
+			}
+			config := DefaultConfig
+			config.StrictValidation = true
+			tt.setup(&config)
+
+			result, err := NewFeedFetcherWithParser(config, parser).FetchFull(context.Background(), "https://example.com/feed")
+			require.NoError(t, err, "a filter, not a validation failure")
+			assert.Len(t, result.Items, 1)
+			require.Len(t, result.ItemErrors, 1)
+			assert.ErrorIs(t, result.ItemErrors[0], tt.wantErr)
+		})
+	}
+
+	t.Run("FutureDriftTolerance still fails", func(t *testing.T) {
+		parser := &MockFeedParser{
+			MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+				return &gofeed.Feed{Items: []*gofeed.Item{
+					{Title: "Future", Link: "/future", PublishedParsed: timePtr(now.Add(48 * time.Hour))},
+				}}, nil
+			},
+		}
+		config := DefaultConfig
+		config.StrictValidation = true
+		_, err := NewFeedFetcherWithParser(config, parser).FetchFull(context.Background(), "https://example.com/feed")
+		assert.ErrorIs(t, err, ErrFuturePublication)
+		assert.NotErrorIs(t, err, ErrAfterMaxPublishDate)
+	})
+}
+
+func TestFeedFetcher_DuplicateGUIDs(t *testing.T) {
+	body := `<?xml version="1.0"?><rss version="2.0"><channel><title>Broken</title>` +
+		`<item><title>One</title><link>https://example.com/1</link><guid>same</guid></item>` +
+		`<item><title>Two</title><link>https://example.com/2</link><guid>same</guid></item>` +
+		`<item><title>Three</title><link>https://example.com/3</link><guid>unique</guid></item>` +
+		`<item><title>Four</title><link>https://example.com/4</link></item>` +
+		`</channel></rss>`
+	pubDate := time.Now().Add(-time.Hour)
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			feed, err := gofeed.NewParser().ParseString(body)
+			for _, item := range feed.Items {
+				item.PublishedParsed = &pubDate
+			}
+			return feed, err
+		},
+	}
+
+	items, stats, err := NewFeedFetcherWithParser(DefaultConfig, mockParser).
+		FetchAndProcessWithStats(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	require.Len(t, items, 4, "duplicates are kept")
+	assert.True(t, items[0].DuplicateGUID)
+	assert.True(t, items[1].DuplicateGUID)
+	assert.False(t, items[2].DuplicateGUID)
+	assert.False(t, items[3].DuplicateGUID, "items without a GUID are never duplicates")
+	assert.Equal(t, "same", items[0].GUID)
+	assert.Equal(t, 2, stats.DuplicateGUIDs)
+}
+
+func TestFeedFetcher_MaxItemsByFeedType(t *testing.T) {
+	now := time.Now()
+	var items []*gofeed.Item
+	for i := range 10 {
+		items = append(items, &gofeed.Item{Title: fmt.Sprintf("Item %d", i), Link: fmt.Sprintf("/%d", i), PublishedParsed: timePtr(now)})
+	}
+	feedTypes := map[string]string{
+		"https://example.com/rss":  "rss",
+		"https://example.com/atom": "atom",
+		"https://example.com/json": "json",
+	}
+	config := DefaultConfig
+	config.MaxItems = 3
+	config.MaxItemsByFeedType = map[string]int{"atom": 8, "json": -1}
+	fetcher := NewFeedFetcherWithParser(config, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{FeedType: feedTypes[url], Items: items}, nil
+		},
+	})
+
+	tests := []struct {
+		url  string
+		opts FetchOptions
+		want int
+	}{
+		{"https://example.com/rss", FetchOptions{}, 3},
+		{"https://example.com/atom", FetchOptions{}, 8},
+		{"https://example.com/json", FetchOptions{}, 10},
+		{"https://example.com/atom", FetchOptions{MaxItems: 2}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(feedTypes[tt.url], func(t *testing.T) {
+			got, err := fetcher.FetchAndProcessWithOptions(context.Background(), tt.url, tt.opts)
+			require.NoError(t, err)
+			assert.Len(t, got, tt.want)
+		})
+	}
+}
+
+func TestFeedFetcher_DropSelfReferentialLinks(t *testing.T) {
+	now := time.Now()
+	data := &gofeed.Feed{
+		Link: "https://www.example.com/",
+		Items: []*gofeed.Item{
+			{Title: "Article", Link: "/news/1", PublishedParsed: timePtr(now)},
+			{Title: "Feed itself", Link: "https://example.com/feed.xml", PublishedParsed: timePtr(now)},
+			{Title: "Home page", Link: "http://example.com", PublishedParsed: timePtr(now)},
+			{Title: "Home fragment", Link: "/#top", PublishedParsed: timePtr(now)},
+		},
+	}
+	parser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return data, nil
+		},
+	}
+
+	items, err := NewFeedFetcherWithParser(DefaultConfig, parser).FetchAndProcess(context.Background(), "https://example.com/feed.xml")
+	require.NoError(t, err)
+	assert.Len(t, items, 4, "kept by default")
+
+	config := DefaultConfig
+	config.DropSelfReferentialLinks = true
+	config.StrictValidation = true
+	result, err := NewFeedFetcherWithParser(config, parser).FetchFull(context.Background(), "https://example.com/feed.xml")
+	require.NoError(t, err, "a filter, not a validation failure")
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Article", result.Items[0].Headline)
+	require.Len(t, result.ItemErrors, 3)
+	for _, itemErr := range result.ItemErrors {
+		assert.ErrorIs(t, itemErr, ErrSelfReferentialURL)
+	}
+
+	got, itemErrs := ConvertItems(mustParseURL(t, "https://example.com/feed.xml"), data.Items, config)
+	assert.Len(t, got, 3, "ConvertItems only knows the feed url")
+	assert.Len(t, itemErrs, 1)
+}
+
+func TestConvertItems_UnescapeLinkEntities(t *testing.T) {
+	doc := `<rss version="2.0"><channel><title>Shop</title><item><title>Deal</title>
+		<link>https://example.com/item?id=7&amp;amp;ref=rss</link>
+		<pubDate>` + time.Now().Format(time.RFC1123Z) + `</pubDate></item></channel></rss>`
+
+	config := DefaultConfig
+	config.UnescapeLinkEntities = true
+	items, err := NewFeedFetcher(config).ParseReader("https://example.com/feed", strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "https://example.com/item?id=7&ref=rss", items[0].URL)
+}
+
+func TestConvertItems_RejectCrossDomainLinks(t *testing.T) {
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "Same host", Link: "/a", PublishedParsed: timePtr(now)},
+		{Title: "Sibling", Link: "//www.example.com/b", PublishedParsed: timePtr(now)},
+		{Title: "Elsewhere", Link: "//evil.com/c", PublishedParsed: timePtr(now)},
+	}
+	feedURL := mustParseURL(t, "https://news.example.com/feed")
+	config := DefaultConfig
+	config.RejectCrossDomainLinks = true
+
+	got, itemErrs := ConvertItems(feedURL, items, config)
+	require.Len(t, got, 1)
+	assert.Equal(t, "Same host", got[0].Headline)
+	require.Len(t, itemErrs, 2)
+	assert.ErrorIs(t, itemErrs[0], ErrCrossDomainLink)
+
+	config.AllowSameSiteLinks = true
+	got, _ = ConvertItems(feedURL, items, config)
+	assert.Len(t, got, 2)
+}
@@ -0,0 +1,21 @@
+package feedfetcher
+
+import (
+	"time"
+
+	"github.com/reddot-watch/feedfetcher/internal/dateparser"
+)
+
+// DateLayouts returns the time layouts tried, in order, when a feed item's
+// publication date could not be parsed by gofeed. The returned slice is a
+// copy and may be modified freely.
+func DateLayouts() []string {
+	return dateparser.Layouts()
+}
+
+// ParseDateWithLocation parses s with the same layouts the fetcher falls back
+// to, interpreting dates without zone information in loc. A nil loc means
+// UTC. The result keeps the zone it was parsed in; call UTC to convert it.
+func ParseDateWithLocation(s string, loc *time.Location) (time.Time, error) {
+	return dateparser.ParseDateWithLocation(s, loc)
+}
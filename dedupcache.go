@@ -0,0 +1,95 @@
+package feedfetcher
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DedupCache remembers recently seen items across fetches, so a process
+// polling the same feeds repeatedly only gets each item once within a time
+// window; see WithDedupCache. Items are keyed by ContentHash, so an edited
+// item counts as new.
+//
+// An entry expires ttl after the item was last seen; an item that stays in
+// its feed is therefore suppressed for as long as it keeps being fetched.
+// When the cache holds maxEntries items, the one seen least recently is
+// evicted to make room. Memory use is bounded by maxEntries, at roughly 150
+// bytes per entry. A DedupCache is safe for concurrent use and may be shared
+// by several fetchers.
+type DedupCache struct {
+	ttl        time.Duration
+	maxEntries int
+	mu         sync.Mutex
+	entries    map[string]*list.Element // of *dedupEntry
+	lru        *list.List               // most recently seen first
+	now        func() time.Time
+}
+
+type dedupEntry struct {
+	key      string
+	lastSeen time.Time
+}
+
+// NewDedupCache creates a DedupCache whose entries expire ttl after they were
+// last seen, holding at most maxEntries items. A ttl <= 0 keeps entries until
+// they are evicted; maxEntries <= 0 means no size limit, leaving memory
+// bounded only by the ttl.
+func NewDedupCache(ttl time.Duration, maxEntries int) *DedupCache {
+	return &DedupCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		now:        time.Now,
+	}
+}
+
+// Seen reports whether key was seen within the window and records it as
+// seen now either way.
+func (c *DedupCache) Seen(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.expire(now)
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*dedupEntry).lastSeen = now
+		c.lru.MoveToFront(elem)
+		return true
+	}
+
+	c.entries[key] = c.lru.PushFront(&dedupEntry{key: key, lastSeen: now})
+	if c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+	return false
+}
+
+// Len returns the number of items in the cache, including expired entries
+// that have not been dropped yet.
+func (c *DedupCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// expire drops entries not seen within the ttl. The list is ordered by last
+// sighting, so they are all at its back.
+func (c *DedupCache) expire(now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	for elem := c.lru.Back(); elem != nil; elem = c.lru.Back() {
+		if now.Sub(elem.Value.(*dedupEntry).lastSeen) < c.ttl {
+			return
+		}
+		c.remove(elem)
+	}
+}
+
+func (c *DedupCache) remove(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*dedupEntry).key)
+}
@@ -0,0 +1,85 @@
+package feedfetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestDedupCache_TTL(t *testing.T) {
+	now := time.Now()
+	cache := NewDedupCache(time.Hour, 0)
+	cache.now = func() time.Time { return now }
+
+	assert.False(t, cache.Seen("a"))
+	assert.True(t, cache.Seen("a"))
+
+	now = now.Add(50 * time.Minute)
+	assert.False(t, cache.Seen("b"))
+	assert.True(t, cache.Seen("a"), "seeing an item again extends its window")
+
+	now = now.Add(30 * time.Minute)
+	assert.True(t, cache.Seen("a"))
+
+	now = now.Add(31 * time.Minute)
+	assert.True(t, cache.Seen("a"))
+	assert.Equal(t, 1, cache.Len(), "b expired")
+	assert.False(t, cache.Seen("b"))
+}
+
+func TestDedupCache_MaxEntries(t *testing.T) {
+	cache := NewDedupCache(0, 2)
+
+	assert.False(t, cache.Seen("a"))
+	assert.False(t, cache.Seen("b"))
+	assert.True(t, cache.Seen("a"))
+	assert.False(t, cache.Seen("c"), "evicts b, the least recently seen")
+	assert.Equal(t, 2, cache.Len())
+
+	assert.True(t, cache.Seen("a"))
+	assert.True(t, cache.Seen("c"))
+	assert.False(t, cache.Seen("b"))
+}
+
+func TestFeedFetcher_WithDedupCache(t *testing.T) {
+	bodies := []string{
+		rssDocument(time.Now(), "First", "Second"),
+		rssDocument(time.Now(), "First", "Second", "Third"),
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, bodies[min(requests, len(bodies)-1)])
+		requests++
+	}))
+	defer server.Close()
+
+	cache := NewDedupCache(time.Hour, 100)
+	fetcher := NewDefaultFeedFetcher().WithDedupCache(cache)
+	fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	items, err = fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "Third", items[0].Headline)
+
+	items, err = fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Empty(t, items)
+	assert.Equal(t, 3, cache.Len())
+
+	// Fetchers without the cache are unaffected
+	items, err = NewDefaultFeedFetcher().FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 3)
+}
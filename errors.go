@@ -0,0 +1,102 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+	"github.com/reddot-watch/feedfetcher/internal/limiter"
+	"github.com/reddot-watch/feedfetcher/internal/validation"
+)
+
+var (
+	ErrInvalidGzip           = errors.New("invalid gzip data")
+	ErrInvalidConfig         = errors.New("invalid configuration")
+	ErrCircuitOpen           = errors.New("circuit open: domain is failing, fetch skipped")
+	ErrParseTimeout          = errors.New("feed parsing timed out")
+	ErrBlockedDomain         = errors.New("domain is blocked")
+	ErrSelfReferentialURL    = errors.New("item links to the feed or its site")
+	ErrContentTooShort       = errors.New("item content is shorter than the minimum length")
+	ErrBatchLimitReached     = errors.New("batch item limit reached, feed skipped")
+	ErrDomainNotAllowed      = errors.New("domain is not in the allowed list")
+	ErrPrivateNetwork        = errors.New("address is in a private network")
+	ErrTooManyRedirects      = errors.New("too many redirects")
+	ErrInsecureRedirect      = errors.New("redirect downgrades the connection")
+	ErrRedirectLoop          = errors.New("redirect loop")
+	ErrPermanentlyMoved      = errors.New("feed has permanently moved")
+	ErrRateLimited           = limiter.ErrRateLimited
+	ErrUnexpectedContentType = errors.New("response is not a feed content type")
+	ErrSoftError             = errors.New("server returned an HTML page instead of the feed")
+	ErrBodyTooLarge          = feedparser.ErrBodyTooLarge
+)
+
+// Reasons an item is rejected, as wrapped by ItemError and, with
+// StrictValidation, by the FeedError of the fetch.
+var (
+	ErrInvalidURL                = validation.ErrInvalidURL
+	ErrCrossDomainLink           = validation.ErrCrossDomainLink
+	ErrURLTooLong                = validation.ErrURLTooLong
+	ErrEmptyHeadline             = validation.ErrEmptyHeadline
+	ErrHeadlineTooLong           = validation.ErrHeadlineTooLong
+	ErrMissingPublishDate        = validation.ErrMissingPublishDate
+	ErrFeedPublicationDateFormat = validation.ErrFeedPublicationDateFormat
+	ErrPublicationTooOld         = validation.ErrPublicationTooOld
+	ErrFuturePublication         = validation.ErrFuturePublication
+	ErrAfterMaxPublishDate       = validation.ErrAfterMaxDate // also an ErrFuturePublication
+)
+
+// Phase identifies the stage of feed processing in which an error occurred.
+type Phase string
+
+const (
+	PhaseDownload Phase = "download"
+	PhaseParse    Phase = "parse"
+	PhaseValidate Phase = "validate"
+)
+
+// FeedError wraps an error with the feed url and the processing phase it
+// occurred in, so callers can log or branch on them without parsing strings.
+type FeedError struct {
+	FeedURL string
+	Phase   Phase
+	Err     error
+}
+
+func (e *FeedError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Phase, e.FeedURL, e.Err)
+}
+
+// Unwrap returns the underlying cause.
+func (e *FeedError) Unwrap() error {
+	return e.Err
+}
+
+func newFeedError(feedURL string, phase Phase, err error) error {
+	return &FeedError{FeedURL: feedURL, Phase: phase, Err: err}
+}
+
+// parserErrorPhase reports whether an error returned by the parser happened
+// while talking to the server or while parsing the response body.
+func parserErrorPhase(err error) Phase {
+	var (
+		urlErr  *url.Error
+		netErr  net.Error
+		httpErr gofeed.HTTPError
+	)
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, context.Canceled),
+		errors.Is(err, ErrUnexpectedContentType),
+		errors.Is(err, ErrBodyTooLarge),
+		errors.As(err, &urlErr),
+		errors.As(err, &netErr),
+		errors.As(err, &httpErr):
+		return PhaseDownload
+	default:
+		return PhaseParse
+	}
+}
@@ -18,8 +18,9 @@ func main() {
 
 	// Parse command line arguments
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: go run example.go <feed_url> [max_items]")
+		fmt.Println("Usage: go run example.go <feed_url|-> [max_items]")
 		fmt.Println("Example: go run example.go https://news.ycombinator.com/rss 10")
+		fmt.Println("Use - to read the feed from standard input: curl -s <feed_url> | go run example.go -")
 		os.Exit(1)
 	}
 
@@ -47,9 +48,14 @@ func main() {
 		Dur("max_age", 7*24*time.Hour).
 		Msg("Fetching feed")
 
-	// Fetch and process the feed
-	ctx := context.Background()
-	items, err := fetcher.FetchAndProcess(ctx, feedURL)
+	// Fetch and process the feed, or parse it from stdin
+	var items []*feedfetcher.FeedItem
+	var err error
+	if feedURL == "-" {
+		items, err = fetcher.ParseReader(feedURL, os.Stdin)
+	} else {
+		items, err = fetcher.FetchAndProcess(context.Background(), feedURL)
+	}
 	if err != nil {
 		log.Fatal().Err(err).Str("url", feedURL).Msg("Failed to fetch feed")
 	}
@@ -0,0 +1,74 @@
+package feedfetcher
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvColumns is the header row written by WriteCSV. New columns are only
+// ever appended so existing consumers can keep reading by position.
+var csvColumns = []string{"id", "feed_url", "url", "headline", "content", "published_at", "content_hash", "summary"}
+
+// WriteJSONL writes items to w as JSON lines, one object per item, using the
+// same encoding as json.Marshal. Times are encoded in RFC 3339 format. Nil
+// items are skipped.
+func WriteJSONL(w io.Writer, items []*FeedItem) error {
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes items to w as CSV with a header row. The columns are id,
+// feed_url, url, headline, content, published_at, content_hash and summary,
+// in that order; id is empty when zero, and published_at is RFC 3339 and
+// empty for items without a date. Links are not included. Nil items are
+// skipped.
+func WriteCSV(w io.Writer, items []*FeedItem) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		var published string
+		if !item.PublishedAt.IsZero() {
+			published = item.PublishedAt.Format(time.RFC3339)
+		}
+
+		var id string
+		if item.ID != 0 {
+			id = strconv.FormatInt(item.ID, 10)
+		}
+
+		record := []string{
+			id,
+			item.FeedURL,
+			item.URL,
+			item.Headline,
+			item.Content,
+			published,
+			item.ContentHash,
+			item.Summary,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,64 @@
+package feedfetcher
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportItems() []*FeedItem {
+	return []*FeedItem{
+		{
+			ID:          1,
+			FeedURL:     "https://example.com/feed",
+			URL:         "https://example.com/a",
+			Headline:    `Quotes "and", commas`,
+			Content:     "line one\nline two",
+			Summary:     "line one",
+			PublishedAt: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+			ContentHash: "abc",
+		},
+		nil,
+		{ID: 2, FeedURL: "https://example.com/feed", URL: "https://example.com/b", Headline: "Undated"},
+	}
+}
+
+func TestWriteJSONL(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJSONL(&buf, exportItems()))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var first FeedItem
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	assert.Equal(t, *exportItems()[0], first)
+	assert.Contains(t, lines[0], `"PublishedAt":"2024-03-01T12:30:00Z"`)
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, exportItems()))
+
+	want := "id,feed_url,url,headline,content,published_at,content_hash,summary\n" +
+		"1,https://example.com/feed,https://example.com/a,\"Quotes \"\"and\"\", commas\",\"line one\nline two\",2024-03-01T12:30:00Z,abc,line one\n" +
+		"2,https://example.com/feed,https://example.com/b,Undated,,,,\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestExport_ZeroID(t *testing.T) {
+	items := []*FeedItem{{FeedURL: "https://example.com/feed", URL: "https://example.com/a", Headline: "No ID"}}
+
+	var jsonl bytes.Buffer
+	require.NoError(t, WriteJSONL(&jsonl, items))
+	assert.NotContains(t, jsonl.String(), `"ID"`)
+
+	var csvOut bytes.Buffer
+	require.NoError(t, WriteCSV(&csvOut, items))
+	assert.Contains(t, csvOut.String(), "\n,https://example.com/feed,")
+}
@@ -0,0 +1,136 @@
+// Package feedfetchertest provides an HTTP server for testing code that
+// fetches feeds, with canned responses for the cases fetchers have to handle:
+// not modified, rate limited and slow servers.
+package feedfetchertest
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RSSContentType is the Content-Type sent when a Response does not set one.
+const RSSContentType = "application/rss+xml; charset=utf-8"
+
+// Response describes what the Server sends for each request.
+type Response struct {
+	Status int         // Status code; 0 means 200
+	Header http.Header // Extra response headers
+	Body   string      // Response body, e.g. from RSS
+	Delay  time.Duration
+}
+
+// NotModified returns a 304 response with no body.
+func NotModified() Response {
+	return Response{Status: http.StatusNotModified}
+}
+
+// RateLimited returns a 429 response asking the client to retry after d,
+// rounded down to whole seconds.
+func RateLimited(d time.Duration) Response {
+	header := http.Header{}
+	header.Set("Retry-After", strconv.Itoa(int(d/time.Second)))
+	return Response{Status: http.StatusTooManyRequests, Header: header}
+}
+
+// Server is an httptest.Server serving a configurable Response. It is safe
+// to change the response while requests are in flight.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	response Response
+	requests []*http.Request
+}
+
+// NewServer starts a Server sending resp for every request. Call Close when
+// done with it.
+func NewServer(resp Response) *Server {
+	s := &Server{response: resp}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewFeedServer starts a Server serving body with status 200.
+func NewFeedServer(body string) *Server {
+	return NewServer(Response{Body: body})
+}
+
+// SetResponse changes the response sent for subsequent requests.
+func (s *Server) SetResponse(resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.response = resp
+}
+
+// Requests returns the requests received so far. Their bodies have been
+// consumed.
+func (s *Server) Requests() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	resp := s.response
+	s.requests = append(s.requests, r.Clone(r.Context()))
+	s.mu.Unlock()
+
+	if resp.Delay > 0 {
+		timer := time.NewTimer(resp.Delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	for key, values := range resp.Header {
+		w.Header()[key] = values
+	}
+	if w.Header().Get("Content-Type") == "" && resp.Body != "" {
+		w.Header().Set("Content-Type", RSSContentType)
+	}
+
+	status := resp.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+	fmt.Fprint(w, resp.Body)
+}
+
+// Item is a feed entry rendered by RSS.
+type Item struct {
+	Title     string
+	Link      string
+	Published time.Time // Omitted from the feed if zero
+	Content   string
+}
+
+// RSS renders items as an RSS 2.0 document.
+func RSS(items ...Item) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel>`)
+	b.WriteString(`<title>Test feed</title><link>https://example.com/</link>`)
+	for _, item := range items {
+		b.WriteString(`<item>`)
+		fmt.Fprintf(&b, `<title>%s</title><link>%s</link>`, html.EscapeString(item.Title), html.EscapeString(item.Link))
+		if !item.Published.IsZero() {
+			fmt.Fprintf(&b, `<pubDate>%s</pubDate>`, item.Published.Format(time.RFC1123Z))
+		}
+		if item.Content != "" {
+			fmt.Fprintf(&b, `<description>%s</description>`, html.EscapeString(item.Content))
+		}
+		b.WriteString(`</item>`)
+	}
+	b.WriteString(`</channel></rss>`)
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package feedfetchertest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, ctx context.Context, url string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, ""
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestServer(t *testing.T) {
+	published := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	server := NewFeedServer(RSS(Item{Title: "Fish & Chips", Link: "https://example.com/a", Published: published}))
+	defer server.Close()
+
+	resp, body := get(t, context.Background(), server.URL)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, RSSContentType, resp.Header.Get("Content-Type"))
+
+	feed, err := gofeed.NewParser().ParseString(body)
+	require.NoError(t, err)
+	require.Len(t, feed.Items, 1)
+	assert.Equal(t, "Fish & Chips", feed.Items[0].Title)
+	assert.True(t, feed.Items[0].PublishedParsed.Equal(published))
+
+	server.SetResponse(NotModified())
+	resp, body = get(t, context.Background(), server.URL)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Empty(t, body)
+
+	server.SetResponse(RateLimited(90 * time.Second))
+	resp, _ = get(t, context.Background(), server.URL)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "90", resp.Header.Get("Retry-After"))
+
+	assert.Len(t, server.Requests(), 3)
+}
+
+func TestServer_Delay(t *testing.T) {
+	server := NewServer(Response{Body: RSS(), Delay: time.Minute})
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resp, _ := get(t, ctx, server.URL)
+	assert.Nil(t, resp, "request times out before the response")
+	assert.Less(t, time.Since(start), 10*time.Second)
+}
@@ -1,17 +1,26 @@
 package feedfetcher
 
 import (
+	"bytes"
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"golang.org/x/time/rate"
 
 	"github.com/mmcdole/gofeed"
+	"github.com/reddot-watch/feedfetcher/internal/breaker"
+	"github.com/reddot-watch/feedfetcher/internal/domain"
 	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+	"github.com/reddot-watch/feedfetcher/internal/latency"
 	"github.com/reddot-watch/feedfetcher/internal/limiter"
+	"github.com/reddot-watch/feedfetcher/internal/novelty"
 	"github.com/reddot-watch/feedfetcher/internal/validation"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -24,49 +33,298 @@ var DefaultConfig = Config{
 	MaxHeadingLength:     250,
 	MaxAge:               24 * time.Hour,
 	FutureDriftTolerance: 24 * time.Hour,
+	MaxRedirects:         10,
+	IncludeContent:       true,
 }
 
 // Config holds the configuration for the feed fetcher.
 type Config struct {
 	UserAgent            string
 	RequestTimeout       time.Duration
-	MaxItems             int // Use 0 or negative value for no limit
+	ParseTimeout         time.Duration // Bounds parsing separately from RequestTimeout; 0 = RequestTimeout covers both
+	MaxRateLimitWait     time.Duration // Fail with ErrRateLimited instead of waiting longer for the rate limiter; 0 = no cap
+	SlowFetchThreshold   time.Duration // Log a warning when downloading and parsing a feed takes longer than this; 0 disables
+	MaxItems             int           // Accept no more than this many items of a feed; 0 or negative for no limit
+	MaxScanItems         int           // Look at no more than this many items of a feed, however many are accepted; 0 scans all
+	MaxBodySize          int64         // Fail downloads of more than this many bytes with ErrBodyTooLarge; 0 = no limit
 	MaxHeadingLength     int
-	MaxAge               time.Duration
-	FutureDriftTolerance time.Duration
+	MaxAge               time.Duration // Use 0 or negative value for no limit
+	FutureDriftTolerance time.Duration // 0 rejects any future date; must not be negative
+	FutureSkewWindow     time.Duration // Extend FutureDriftTolerance by the feed's clock skew, estimated from items up to this far ahead; 0 disables
+	MinPublishDate       time.Time     // Items published before this are rejected as too old, on top of MaxAge; zero disables
+	MaxPublishDate       time.Time     // Items published after this are rejected as future, on top of FutureDriftTolerance; zero disables
+	DedupHeadlines       bool          // Drop items repeating an earlier headline in the same fetch
+	IncludeContent       bool          // Fill in FeedItem.Content; DefaultConfig sets it, turn it off to save memory when only headlines are needed
+	ExtractCanonicalURL  bool          // Set FeedItem.CanonicalURL from a <link rel="canonical"> in the item's content
+	FaviconFallback      bool          // Derive FeedMeta.IconURL from the site's /favicon.ico when the feed has no icon
+	UseGUIDAsURLFallback bool          // Use the item GUID as its URL when the link is empty and the GUID is an absolute URL
+	IgnoreXMLBase        bool          // Resolve relative item links against the feed url even where an RSS feed declares xml:base
+	UnescapeLinkEntities bool          // Decode HTML entities such as &amp; left in item links by feeds that escape them twice
+	MaxDateLength        int           // Date strings longer than this are rejected unparsed; 0 uses the default of 64
+	MaxURLLength         int           // Drop items whose resolved link is longer than this many bytes; 0 = no limit
+	StrictContentType    bool          // Fail with ErrUnexpectedContentType unless the response has a feed Content-Type
+	SummaryWords         int           // Replace FeedItem.Summary with the first N words of its text, or of Content without one; 0 disables
+	MinContentLength     int           // Drop items whose content text, stripped of HTML, has fewer runes than this; 0 disables
+	StrictValidation     bool          // Fail the fetch with the first invalid item's ItemError instead of dropping the item
+	KeepOldItems         bool          // Keep items too old for MaxAge or MinPublishDate, with FeedItem.Archived set, instead of dropping them
+
+	// DisableDateParserFallback uses only the publication dates gofeed
+	// parsed itself. Items whose date gofeed could not parse fail with
+	// ErrMissingPublishDate instead of going through this package's wider
+	// set of layouts, which is faster and more predictable for feeds known
+	// to be well-formed. DefaultFeedTimezone then has no effect.
+	DisableDateParserFallback bool
+
+	// DefaultFeedTimezone is the time zone of publication dates that do not
+	// carry one, for regional feeds that give local times without an
+	// offset. Nil means UTC.
+	DefaultFeedTimezone *time.Location
+
+	// MaxItemsByFeedType replaces MaxItems for fetched feeds of the given
+	// types, "rss", "atom" or "json", e.g. to keep more of the history
+	// Atom feeds tend to carry. Types not listed use MaxItems. A MaxItems
+	// passed in FetchOptions still takes precedence.
+	MaxItemsByFeedType map[string]int
+
+	// RejectCrossDomainLinks drops items whose link resolves to a different
+	// host than the feed's, for callers that only want links back to the
+	// publisher's own site. AllowSameSiteLinks relaxes it to accept any host
+	// under the feed's registrable domain, so a feed on feeds.example.com
+	// may link to www.example.com.
+	RejectCrossDomainLinks bool
+	AllowSameSiteLinks     bool
+
+	// BlockedDomains are never fetched, nor are their subdomains; see
+	// WithBlockedDomains. With DropBlockedLinks, items linking to them are
+	// dropped too.
+	BlockedDomains   []string
+	DropBlockedLinks bool
+
+	// DropSelfReferentialLinks drops items whose link resolves to the feed
+	// url or to the feed's site link, with ErrSelfReferentialURL, as some
+	// broken feeds give every item. URLs are compared ignoring the scheme,
+	// a www. prefix, the fragment and a trailing slash.
+	DropSelfReferentialLinks bool
+
+	// AllowedDomains, when not empty, restricts fetching to these domains
+	// and their subdomains; see WithAllowedDomains.
+	AllowedDomains []string
+
+	// BlockPrivateNetworks refuses connections to private, loopback and
+	// link-local addresses with ErrPrivateNetwork. The check runs on the
+	// resolved address at dial time, so it also catches host names that
+	// resolve, or are rebound, to internal addresses. It does not apply to
+	// fetches through a SOCKS5 proxy, which resolves host names itself.
+	BlockPrivateNetworks bool
+
+	// DNSCacheTTL, when positive, caches successful host name lookups for
+	// up to this long. The resolver does not expose record TTLs, so keep it
+	// at or below the TTLs of the feeds' DNS records, or resolve through
+	// WithDNSLookup to cache each answer for its own TTL. The most recently
+	// used hosts are kept, and the cache is shared by the fetchers derived
+	// from this one through the With methods that keep the resolver. Cached
+	// addresses are still checked by BlockPrivateNetworks on every
+	// connection.
+	DNSCacheTTL time.Duration
+
+	// MaxRedirects is the number of redirects followed before failing with
+	// ErrTooManyRedirects; 0 uses the default of 10 and a negative value
+	// refuses all redirects. Redirects from https to http, or to a non-http
+	// scheme, fail with ErrInsecureRedirect unless AllowInsecureRedirect is
+	// set.
+	MaxRedirects          int
+	AllowInsecureRedirect bool
+
+	// ReportPermanentRedirect stops a fetch whose feed url answers with a
+	// 301 or 308 with a *PermanentRedirectError carrying the new location,
+	// instead of following it, so the caller can store the new url. A feed
+	// moved back to a url it was earlier reported as moved away from fails
+	// with ErrRedirectLoop instead. Loops within one chain of redirects
+	// always fail with ErrRedirectLoop.
+	ReportPermanentRedirect bool
+
+	// URLNormalizer, if set, rewrites item URLs and links into a canonical
+	// form; StripTrackingParams is a ready-made choice. Content is left
+	// alone unless NormalizeContentLinks is also set, in which case the
+	// href of every <a> element in it is rewritten too.
+	URLNormalizer         URLNormalizer
+	NormalizeContentLinks bool
+
+	// MaxRetries is the number of times a download is retried after a
+	// transient failure: a network error, a timeout, or a 5xx or 429
+	// response. The first retry waits RetryBackoff (1 second if zero) and
+	// each further one twice as long as the one before. RetrySoftErrors
+	// also retries HTML pages served in place of the feed (ErrSoftError).
+	// MaxTotalFetchTime, if positive, bounds all attempts of one fetch and
+	// the waits between them; once it runs out the last error is returned.
+	MaxRetries        int
+	RetryBackoff      time.Duration
+	RetrySoftErrors   bool
+	MaxTotalFetchTime time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failed fetches
+	// after which a domain is skipped for CircuitBreakerCooldown (5 minutes
+	// if zero). Use 0 to disable the circuit breaker.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+}
+
+// Validate reports whether the configuration is usable. Fetches made with an
+// invalid configuration fail with an error wrapping ErrInvalidConfig.
+func (c Config) Validate() error {
+	if c.FutureDriftTolerance < 0 {
+		return fmt.Errorf("%w: negative FutureDriftTolerance %v", ErrInvalidConfig, c.FutureDriftTolerance)
+	}
+	if c.MaxBodySize < 0 {
+		return fmt.Errorf("%w: negative MaxBodySize %d", ErrInvalidConfig, c.MaxBodySize)
+	}
+	return nil
 }
 
-// FeedItem represents a single item from a feed.
+// FeedItem represents a single item from a feed. ID is never set by the
+// feed itself: it is 0 unless an IDGenerator is configured (see
+// WithIDGenerator), or owned by the caller, e.g. for a database key. A zero
+// ID is omitted from JSON and left empty in CSV exports.
 type FeedItem struct {
-	ID          int64
+	ID          int64 `json:",omitempty"`
 	FeedURL     string
 	URL         string
+	GUID        string // The item's guid or Atom id as given in the feed; may be empty
 	Headline    string
-	Content     string
+	Content     string // Full content; the description when the item has nothing else
+	Summary     string // The description when the item also has full content; plain text when Config.SummaryWords is set
 	PublishedAt time.Time
+	Links       []Link // All links of the item, including URL
+	FeedIndex   int    // Position of the item in the feed as published, counting items that were dropped
+	ContentHash string // See ContentHash; taken before URLNormalizer, NormalizeContentLinks, SummaryWords or IncludeContent change the item
+	Archived    bool   // Too old for MaxAge or MinPublishDate, but kept because of Config.KeepOldItems
+
+	// DuplicateGUID is set when another item accepted from the same fetch
+	// has the same GUID, as broken feeds sometimes reuse one for every
+	// item. Such items are kept; all of them are flagged, the first too.
+	DuplicateGUID bool
+
+	// Podcast holds the item's Podcasting 2.0 transcripts and chapters. It
+	// is nil when the feed does not use the podcast namespace for the item.
+	Podcast *Podcast
+
+	// Media lists the Media RSS media:content representations of the item,
+	// including those grouped in media:group.
+	Media []MediaContent
+
+	// CanonicalURL is the target of a <link rel="canonical"> element in the
+	// item's content, resolved against URL, when Config.ExtractCanonicalURL
+	// is set. Syndicated copies often point it at the original article, so
+	// deduplication prefers it over URL.
+	CanonicalURL string
+
+	// SourceName and SourceURL attribute an item republished by an
+	// aggregator to the feed it came from, per its RSS or Atom <source>
+	// element. SourceURL is resolved against the feed url. Both are empty
+	// when the item has no source, or was parsed by a plain gofeed.Parser,
+	// which drops the element.
+	SourceName string
+	SourceURL  string
+}
+
+// SummaryOrContent returns the summary if the item has one and the content
+// otherwise. This is what Content held before the two were separated.
+func (item *FeedItem) SummaryOrContent() string {
+	if item.Summary != "" {
+		return item.Summary
+	}
+	return item.Content
 }
 
-// FeedFetcher handles retrieving and processing feed data.
+// FetchStats describes the work done by a single fetch.
+type FetchStats struct {
+	Duration        time.Duration // Time spent downloading and parsing
+	BytesDownloaded int64         // Size of the decoded response body, after any gzip decompression
+	ItemsFound      int           // Items present in the feed
+	ItemsAccepted   int           // Items that passed validation
+	DuplicateGUIDs  int           // Accepted items whose GUID is shared with another accepted item
+	NewItems        int           // Accepted items not in the previous fetch of the feed; only counted with WithEmptyFetchTracking
+}
+
+// FeedFetcher handles retrieving and processing feed data. A FeedFetcher is
+// safe for concurrent use by multiple goroutines; parsed feeds are never
+// modified, so a Parser may hand the same feed to several fetches.
 type FeedFetcher struct {
 	config      Config
 	parser      feedparser.Parser
 	rateLimiter *limiter.DomainRateLimiter
+	breaker     *breaker.Breaker
+	latency     *latency.Tracker
+	novelty     *novelty.Tracker
 	logger      zerolog.Logger
+	transport   transportOptions
+	userAgents  *userAgentPool
+	domainUAs   map[string]string // registrable domain -> user agent
+
+	headers         http.Header
+	originReferer   bool
+	retryableStatus map[int]bool // nil retries 5xx and 429
+
+	responseHook ResponseHook
+	dateSink     UnparseableDateSink
+	idGenerator  IDGenerator
+	onComplete   FetchCompleteHook
+	customMapper CustomFieldMapper
+	dedupCache   *DedupCache
+	moves        *movedFeeds
+
+	metrics       MetricsRecorder
+	metricsLabels map[string]string
 }
 
+// ResponseHook receives the raw body and headers of a feed response after it
+// has been downloaded and before it is parsed. It must not modify body.
+type ResponseHook func(url string, body []byte, header http.Header)
+
+// UnparseableDateSink receives publication date strings that no known layout
+// could parse, along with the url of the feed they came from.
+type UnparseableDateSink func(feedURL, raw string)
+
+// FetchCompleteHook receives the outcome of every fetch: the feed url as
+// given, the statistics of the fetch and the error it returned, nil on success.
+type FetchCompleteHook func(url string, stats FetchStats, err error)
+
+// CustomFieldMapper receives the elements gofeed could not map for an item,
+// keyed by element name (gofeed's Item.Custom; nil when there are none), and
+// the item converted from it, which it may modify.
+type CustomFieldMapper func(custom map[string]string, item *FeedItem)
+
+// IDGenerator assigns FeedItem.ID to an item once it has been validated;
+// URLID is a ready-made choice.
+type IDGenerator func(item *FeedItem) int64
+
 // NewFeedFetcher creates a new FeedFetcher with the provided configuration.
 func NewFeedFetcher(config Config) *FeedFetcher {
-	parser := feedparser.NewGoFeedParser(config.UserAgent)
-
 	// Default limit 1 req/sec per domain with burst of 3
 	rateLimiter := limiter.NewDomainRateLimiter(rate.Limit(1), 3)
 
-	return &FeedFetcher{
+	f := &FeedFetcher{
 		config:      config,
-		parser:      parser,
 		rateLimiter: rateLimiter,
+		breaker:     newBreaker(config),
+		latency:     latency.New(),
+		moves:       newMovedFeeds(),
 		logger:      log.With().Str("component", "feed_fetcher").Logger(),
 	}
+	f.parser = f.newParser()
+	return f
+}
+
+func newBreaker(config Config) *breaker.Breaker {
+	if config.CircuitBreakerThreshold <= 0 {
+		return nil
+	}
+
+	cooldown := config.CircuitBreakerCooldown
+	if cooldown <= 0 {
+		cooldown = 5 * time.Minute
+	}
+	return breaker.New(config.CircuitBreakerThreshold, cooldown)
 }
 
 // NewDefaultFeedFetcher creates a new FeedFetcher with default configuration.
@@ -92,6 +350,7 @@ func (f *FeedFetcher) WithMaxItems(maxItems int) *FeedFetcher {
 }
 
 // WithMaxAge returns a new FeedFetcher with an updated MaxAge setting.
+// Use duration <= 0 to accept items of any age.
 func (f *FeedFetcher) WithMaxAge(duration time.Duration) *FeedFetcher {
 	newFetcher := *f
 	newConfig := f.config
@@ -108,8 +367,7 @@ func (f *FeedFetcher) WithUserAgent(userAgent string) *FeedFetcher {
 	newFetcher.config = newConfig
 
 	// Special case: also need to update the parser
-	newParser := feedparser.NewGoFeedParser(userAgent)
-	newFetcher.parser = newParser
+	newFetcher.parser = newFetcher.newParser()
 
 	return &newFetcher
 }
@@ -123,6 +381,17 @@ func (f *FeedFetcher) WithRequestTimeout(timeout time.Duration) *FeedFetcher {
 	return &newFetcher
 }
 
+// WithParseTimeout returns a new FeedFetcher with an updated ParseTimeout setting.
+// When set, RequestTimeout only bounds downloading the feed and parsing it is
+// bounded by the parse timeout instead. Use 0 to let RequestTimeout cover both.
+func (f *FeedFetcher) WithParseTimeout(timeout time.Duration) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.ParseTimeout = timeout
+	newFetcher.config = newConfig
+	return &newFetcher
+}
+
 // WithMaxHeadingLength returns a new FeedFetcher with an updated MaxHeadingLength setting.
 func (f *FeedFetcher) WithMaxHeadingLength(length int) *FeedFetcher {
 	newFetcher := *f
@@ -132,7 +401,30 @@ func (f *FeedFetcher) WithMaxHeadingLength(length int) *FeedFetcher {
 	return &newFetcher
 }
 
+// WithMinPublishDate returns a new FeedFetcher with an updated MinPublishDate
+// setting. Items published before floor are dropped even if MaxAge would
+// accept them. Use the zero time to disable the floor.
+func (f *FeedFetcher) WithMinPublishDate(floor time.Time) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.MinPublishDate = floor
+	newFetcher.config = newConfig
+	return &newFetcher
+}
+
+// WithMaxPublishDate returns a new FeedFetcher with an updated MaxPublishDate
+// setting. Items dated after ceiling are dropped even if FutureDriftTolerance
+// would accept them. Use the zero time to disable the ceiling.
+func (f *FeedFetcher) WithMaxPublishDate(ceiling time.Time) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.MaxPublishDate = ceiling
+	newFetcher.config = newConfig
+	return &newFetcher
+}
+
 // WithFutureDriftTolerance returns a new FeedFetcher with an updated FutureDriftTolerance setting.
+// A zero duration rejects any item dated in the future; negative durations are invalid.
 func (f *FeedFetcher) WithFutureDriftTolerance(duration time.Duration) *FeedFetcher {
 	newFetcher := *f
 	newConfig := f.config
@@ -141,34 +433,295 @@ func (f *FeedFetcher) WithFutureDriftTolerance(duration time.Duration) *FeedFetc
 	return &newFetcher
 }
 
+// WithDefaultTimezone returns a new FeedFetcher that reads publication dates
+// without a time zone as times in loc; see Config.DefaultFeedTimezone.
+func (f *FeedFetcher) WithDefaultTimezone(loc *time.Location) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.DefaultFeedTimezone = loc
+	newFetcher.config = newConfig
+	return &newFetcher
+}
+
+// WithDateParserDisabled returns a new FeedFetcher that only accepts
+// publication dates gofeed could parse; see Config.DisableDateParserFallback.
+func (f *FeedFetcher) WithDateParserDisabled() *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.DisableDateParserFallback = true
+	newFetcher.config = newConfig
+	return &newFetcher
+}
+
+// WithStrictValidation returns a new FeedFetcher that fails a fetch with
+// PhaseValidate as soon as any item is invalid, instead of dropping the item,
+// e.g. to catch feed regressions in CI. The error wraps the item's ItemError.
+// Items dropped by a filtering setting, such as MaxAge, MinPublishDate,
+// MaxPublishDate, MinContentLength, MaxURLLength, RejectCrossDomainLinks or
+// the blocked domains, are filtered rather than invalid and do not fail it.
+func (f *FeedFetcher) WithStrictValidation() *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.StrictValidation = true
+	newFetcher.config = newConfig
+	return &newFetcher
+}
+
+// WithCircuitBreaker returns a new FeedFetcher that stops fetching from a
+// domain for cooldown after threshold consecutive failures, returning
+// ErrCircuitOpen instead. After the cooldown a single fetch is let through
+// to probe the domain: it closes the circuit if it succeeds and reopens it if
+// it fails. Use threshold <= 0 to disable the circuit breaker. The new
+// fetcher starts with no recorded failures.
+func (f *FeedFetcher) WithCircuitBreaker(threshold int, cooldown time.Duration) *FeedFetcher {
+	newFetcher := *f
+	newConfig := f.config
+	newConfig.CircuitBreakerThreshold = threshold
+	newConfig.CircuitBreakerCooldown = cooldown
+	newFetcher.config = newConfig
+	newFetcher.breaker = newBreaker(newConfig)
+	return &newFetcher
+}
+
+// WithResponseHook returns a new FeedFetcher that calls hook with every
+// successfully downloaded feed body, e.g. to archive raw feeds or inspect
+// headers. Setting a hook buffers each response body in memory.
+func (f *FeedFetcher) WithResponseHook(hook ResponseHook) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.responseHook = hook
+	return &newFetcher
+}
+
+// WithUnparseableDateSink returns a new FeedFetcher that reports every
+// publication date it fails to parse to sink, e.g. to collect samples for new
+// date layouts. Missing dates are not reported. The sink runs on its own
+// goroutine so it never delays a fetch, and must be safe for concurrent use.
+func (f *FeedFetcher) WithUnparseableDateSink(sink UnparseableDateSink) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.dateSink = sink
+	return &newFetcher
+}
+
+// WithIDGenerator returns a new FeedFetcher that sets the ID of every
+// accepted item to generate(item). Without a generator IDs are left at 0.
+// The generator must be safe for concurrent use if the fetcher is shared.
+func (f *FeedFetcher) WithIDGenerator(generate IDGenerator) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.idGenerator = generate
+	return &newFetcher
+}
+
+// WithOnFetchComplete returns a new FeedFetcher that calls hook once at the
+// end of every fetch, successful or not, e.g. to adapt polling intervals or
+// track feed health. It runs synchronously before the fetch returns, and must
+// be safe for concurrent use if the fetcher is shared.
+func (f *FeedFetcher) WithOnFetchComplete(hook FetchCompleteHook) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.onComplete = hook
+	return &newFetcher
+}
+
+// WithCustomFieldMapper returns a new FeedFetcher that calls mapper for
+// every accepted item, e.g. to read a publisher-specific <priority> element.
+// gofeed only collects such elements for RSS items without a namespace
+// prefix; namespaced elements are not included. The mapper runs before the
+// IDGenerator and must be safe for concurrent use if the fetcher is shared.
+func (f *FeedFetcher) WithCustomFieldMapper(mapper CustomFieldMapper) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.customMapper = mapper
+	return &newFetcher
+}
+
+// WithDedupCache returns a new FeedFetcher that drops items whose
+// ContentHash is in cache, i.e. items already returned by an earlier fetch
+// within the cache's window, and records the rest. Items dropped this way
+// are neither counted as accepted nor reported as item errors.
+func (f *FeedFetcher) WithDedupCache(cache *DedupCache) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.dedupCache = cache
+	return &newFetcher
+}
+
 // FetchAndProcess fetches and processes a feed, returning the parsed items.
 func (f *FeedFetcher) FetchAndProcess(ctx context.Context, feedURL string) ([]*FeedItem, error) {
-	if err := f.rateLimiter.WaitForDomain(ctx, feedURL); err != nil {
-		return nil, err
+	return f.FetchAndProcessWithOptions(ctx, feedURL, FetchOptions{})
+}
+
+// FetchAndProcessWithOptions is like FetchAndProcess but applies the given
+// per-call overrides to this fetch only.
+func (f *FeedFetcher) FetchAndProcessWithOptions(ctx context.Context, feedURL string, opts FetchOptions) ([]*FeedItem, error) {
+	_, items, err := f.fetch(ctx, feedURL, opts)
+	return items, err
+}
+
+// FetchAndProcessWithStats is like FetchAndProcess but also reports statistics
+// about the fetch. Stats are returned on failure too, covering whatever work
+// was done before the error.
+func (f *FeedFetcher) FetchAndProcessWithStats(ctx context.Context, feedURL string) ([]*FeedItem, FetchStats, error) {
+	ff, items, err := f.fetch(ctx, feedURL, FetchOptions{})
+	if ff == nil {
+		return items, FetchStats{}, err
 	}
+	return items, ff.stats, err
+}
 
-	ff, err := f.newFeed(feedURL)
+// fetch runs the full pipeline for a single feed and reports its outcome to
+// the fetch complete hook. The returned feed is nil only when the
+// configuration or feedURL is invalid.
+func (f *FeedFetcher) fetch(ctx context.Context, feedURL string, opts FetchOptions) (*feed, []*FeedItem, error) {
+	ff, items, err := f.runPipeline(ctx, feedURL, opts)
+	if errors.Is(err, context.Canceled) && errors.Is(context.Cause(ctx), ErrBatchLimitReached) {
+		err = newFeedError(feedURL, PhaseDownload, ErrBatchLimitReached)
+	}
+	var stats FetchStats
+	if ff != nil {
+		stats = ff.stats
+	}
+	if f.onComplete != nil {
+		f.onComplete(feedURL, stats, err)
+	}
+	f.recordMetrics(feedURL, opts, stats, err)
+	return ff, items, err
+}
+
+// runPipeline downloads, parses and validates a single feed.
+func (f *FeedFetcher) runPipeline(ctx context.Context, feedURL string, opts FetchOptions) (*feed, []*FeedItem, error) {
+	config := f.configFor(opts)
+	if err := config.Validate(); err != nil {
+		return nil, nil, newFeedError(feedURL, PhaseValidate, err)
+	}
+
+	ff, err := f.prepareFeed(feedURL, opts)
 	if err != nil {
-		return nil, err
+		return ff, nil, err
 	}
 
-	if err := f.download(ctx, ff); err != nil {
-		return nil, err
+	breakerKey := domain.Registrable(ff.parsedURL.Host)
+	if f.breaker != nil && !f.breaker.Allow(breakerKey) {
+		f.logger.Warn().Str("url", feedURL).Str("domain", breakerKey).Msg("circuit open, skipping fetch")
+		return ff, nil, newFeedError(feedURL, PhaseDownload, ErrCircuitOpen)
+	}
+
+	err = f.downloadWithRetries(ctx, ff, config)
+	f.recordOutcome(breakerKey, err)
+	if err != nil {
+		return ff, nil, err
+	}
+	f.latency.Record(breakerKey, ff.stats.Duration)
+
+	items, err := f.extractItems(ff)
+	if err == nil && f.novelty != nil {
+		f.observeNovelty(ff)
+	}
+	return ff, items, err
+}
+
+// PrimeDomains sets up rate limiters for hosts before any fetch, which avoids
+// contention when a large batch starts hitting many new domains at once.
+func (f *FeedFetcher) PrimeDomains(hosts []string) {
+	f.rateLimiter.Prime(hosts)
+}
+
+// SetDomainRateLimit overrides the default rate limit for hosts matching
+// pattern, either an exact host ("example.com") or a wildcard covering its
+// subdomains ("*.wordpress.com"). Exact rules take precedence over wildcards
+// and longer wildcards over shorter ones. The rate limiter is shared with
+// fetchers derived through the With methods, which see the rule too.
+func (f *FeedFetcher) SetDomainRateLimit(pattern string, limit rate.Limit, burst int) {
+	f.rateLimiter.SetLimit(pattern, limit, burst)
+}
+
+// RateLimitStat is a snapshot of the rate limiter for one domain.
+type RateLimitStat struct {
+	Tokens   float64   // Requests that could start right now without waiting
+	LastUsed time.Time // Zero if the domain has not been fetched yet
+}
+
+// RateLimitStats returns the state of every domain's rate limiter, keyed by
+// host with any "www." prefix removed. Domains with no tokens left are the
+// ones where fetches are currently waiting on the rate limit.
+func (f *FeedFetcher) RateLimitStats() map[string]RateLimitStat {
+	stats := f.rateLimiter.Stats()
+	result := make(map[string]RateLimitStat, len(stats))
+	for domain, stat := range stats {
+		result[domain] = RateLimitStat(stat)
 	}
+	return result
+}
 
-	return f.extractItems(ff)
+// LatencyStats summarizes how long successful downloads from a domain took.
+// EMA is an exponential moving average that favors recent fetches and P95 is
+// taken over the last 100 fetches.
+type LatencyStats struct {
+	EMA     time.Duration
+	P95     time.Duration
+	Samples int
+}
+
+// LatencyStats returns the download latency recorded for the registrable
+// domain of host (so "www.example.com" and "example.com" share stats), and
+// whether any fetch from it has succeeded yet. Failed downloads are not
+// included.
+func (f *FeedFetcher) LatencyStats(host string) (LatencyStats, bool) {
+	stats, ok := f.latency.Stats(domain.Registrable(host))
+	return LatencyStats(stats), ok
+}
+
+// recordOutcome feeds a download result into the circuit breaker. Requests
+// canceled by the caller or turned away by the rate limiter say nothing about
+// the domain and are ignored.
+func (f *FeedFetcher) recordOutcome(key string, err error) {
+	switch {
+	case f.breaker == nil, errors.Is(err, context.Canceled), errors.Is(err, ErrRateLimited):
+	case err != nil:
+		f.breaker.Failure(key)
+	default:
+		f.breaker.Success(key)
+	}
 }
 
 type feed struct {
 	url       string
 	parsedURL *url.URL
 	data      *gofeed.Feed
+	opts      FetchOptions
+	stats     FetchStats
+
+	contentType string      // Media type of the response, if known
+	itemErrs    []ItemError // Items dropped or rejected by extractItems
+	seenKeys    []uint64    // URLIDs of the accepted items, for the empty fetch tracker
+}
+
+// prepareFeed sets up a fetch of feedURL with opts: it fills in the user
+// agent and request headers and checks the domain access lists. The feed is
+// returned with the error if only the access check fails.
+func (f *FeedFetcher) prepareFeed(feedURL string, opts FetchOptions) (*feed, error) {
+	ff, err := f.newFeed(feedURL)
+	if err != nil {
+		return nil, err
+	}
+	ff.opts = opts
+	if ff.opts.UserAgent == "" {
+		ff.opts.UserAgent = f.userAgentFor(ff.parsedURL.Host)
+	}
+	ff.opts.Headers = f.requestHeader(ff.parsedURL, opts.Headers)
+
+	if err := checkHost(f.config, ff.parsedURL.Hostname()); err != nil {
+		f.logger.Warn().Str("url", feedURL).Err(err).Msg("domain not permitted, skipping fetch")
+		return ff, newFeedError(feedURL, PhaseValidate, err)
+	}
+	return ff, nil
+}
+
+// siteURL returns the feed's resolved site link, or "" if it has none.
+func (feed *feed) siteURL() string {
+	return resolveOptionalURL(feed.parsedURL, feed.parsedURL, feed.data.Link)
 }
 
 func (f *FeedFetcher) newFeed(feedURL string) (*feed, error) {
 	parsedURL, err := url.Parse(feedURL)
 	if err != nil {
-		return nil, fmt.Errorf("invalid feed url %s: %w", feedURL, err)
+		return nil, newFeedError(feedURL, PhaseValidate, fmt.Errorf("invalid feed url: %w", err))
 	}
 
 	return &feed{
@@ -179,104 +732,360 @@ func (f *FeedFetcher) newFeed(feedURL string) (*feed, error) {
 
 // download retrieves a feed from the given url.
 func (f *FeedFetcher) download(ctx context.Context, feed *feed) error {
-	ctx, cancel := context.WithTimeout(ctx, f.config.RequestTimeout)
-	defer cancel()
+	config := f.configFor(feed.opts)
+	timeout := config.RequestTimeout
 
-	f.logger.Debug().Str("url", feed.url).Msg("downloading feed")
+	f.logger.Debug().Str("url", feed.url).Str("user_agent", config.UserAgent).Msg("downloading feed")
 	startTime := time.Now()
 
-	result, err := f.parser.ParseURLWithContext(feed.url, ctx)
+	result, err := f.parse(ctx, feed, config)
+	feed.stats.Duration = time.Since(startTime)
+	if config.SlowFetchThreshold > 0 && feed.stats.Duration > config.SlowFetchThreshold &&
+		!errors.Is(err, context.DeadlineExceeded) {
+		f.logger.Warn().
+			Str("url", feed.url).
+			Dur("duration", feed.stats.Duration).
+			Dur("threshold", config.SlowFetchThreshold).
+			Msg("slow feed response")
+	}
 	if err != nil {
+		if errors.Is(err, ErrParseTimeout) {
+			f.logger.Error().Str("url", feed.url).Err(err).Msg("parse deadline exceeded")
+			return newFeedError(feed.url, PhaseParse, err)
+		}
+
 		if errors.Is(err, context.DeadlineExceeded) {
-			f.logger.Error().Str("url", feed.url).Err(err).Msg("deadline exceeded")
-			return fmt.Errorf("timed out after %v fetching feed %s: %w",
-				f.config.RequestTimeout, feed.url, err)
+			f.logger.Error().Str("url", feed.url).Str("user_agent", config.UserAgent).Err(err).Msg("deadline exceeded")
+			return newFeedError(feed.url, PhaseDownload,
+				fmt.Errorf("timed out after %v: %w", timeout, err))
 		}
 
 		if errors.Is(err, context.Canceled) {
 			f.logger.Warn().Str("url", feed.url).Msg("request was canceled")
-			return fmt.Errorf("feed fetch canceled for %s: %w", feed.url, err)
+			return newFeedError(feed.url, PhaseDownload, fmt.Errorf("fetch canceled: %w", err))
 		}
 
-		f.logger.Error().Str("url", feed.url).Err(err).Msg("failed to parse feed")
-		return fmt.Errorf("failed to parse feed url %s: %w", feed.url, err)
+		phase := parserErrorPhase(err)
+		f.logger.Error().
+			Str("url", feed.url).
+			Str("phase", string(phase)).
+			Str("user_agent", config.UserAgent).
+			Err(err).
+			Msg("failed to fetch feed")
+		return newFeedError(feed.url, phase, err)
 	}
 
 	feed.data = result
+	feed.stats.ItemsFound = len(result.Items)
 
 	f.logger.Debug().
 		Str("url", feed.url).
-		Dur("duration", time.Since(startTime)).
+		Dur("duration", feed.stats.Duration).
+		Int64("bytes", feed.stats.BytesDownloaded).
 		Int("items", len(feed.data.Items)).
 		Msg("feed downloaded successfully")
 
 	return nil
 }
 
+// parse downloads and parses a feed. Parsers that can fetch without parsing
+// are driven in two steps, which lets the response hook see the raw body and
+// lets parsing be bounded separately from the download.
+func (f *FeedFetcher) parse(ctx context.Context, feed *feed, config Config) (*gofeed.Feed, error) {
+	fetchCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
+	defer cancel()
+
+	fp, ok := f.parser.(feedparser.Fetcher)
+	if !ok {
+		return f.parser.ParseURLWithContext(feed.url, fetchCtx)
+	}
+
+	body, header, err := fp.Fetch(fetchCtx, feedparser.Request{
+		URL:         feed.url,
+		UserAgent:   feed.opts.UserAgent,
+		Header:      feed.opts.Headers,
+		MaxBodySize: config.MaxBodySize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	feed.stats.BytesDownloaded = int64(len(body))
+
+	if f.responseHook != nil {
+		f.responseHook(feed.url, body, header)
+	}
+
+	feed.contentType = mediaType(header.Get("Content-Type"))
+	if !isFeedContentType(feed.contentType) {
+		if config.StrictContentType {
+			return nil, fmt.Errorf("%w: %q", ErrUnexpectedContentType, feed.contentType)
+		}
+		f.logger.Warn().Str("url", feed.url).Str("content_type", feed.contentType).Msg("unexpected content type, parsing anyway")
+	}
+
+	var result *gofeed.Feed
+	if config.ParseTimeout <= 0 {
+		// Whatever is left of RequestTimeout bounds the parse
+		result, err = f.parseBody(fetchCtx, body, 0)
+	} else {
+		result, err = f.parseBody(ctx, body, config.ParseTimeout)
+	}
+	if err != nil && fetchCtx.Err() == nil && !errors.Is(err, ErrParseTimeout) &&
+		isSoftError(feed.contentType, body) {
+		return nil, fmt.Errorf("%w: %w", ErrSoftError, err)
+	}
+	return result, err
+}
+
+// parseBody parses a downloaded feed, giving up once ctx is done or timeout
+// (if positive) elapses. gofeed cannot be interrupted, so an abandoned parse
+// runs on in the background and only its result is discarded.
+func (f *FeedFetcher) parseBody(ctx context.Context, body []byte, timeout time.Duration) (*gofeed.Feed, error) {
+	type result struct {
+		feed *gofeed.Feed
+		err  error
+	}
+	body = trimDocumentPrefix(body)
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		if bp, ok := f.parser.(feedparser.BytesParser); ok {
+			r.feed, r.err = bp.ParseBytes(body)
+		} else {
+			r.feed, r.err = f.parser.Parse(bytes.NewReader(body))
+		}
+		done <- r
+	}()
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case r := <-done:
+		return r.feed, r.err
+	case <-expired:
+		return nil, fmt.Errorf("%w after %v", ErrParseTimeout, timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// utf8BOM is the byte order mark some servers put before a UTF-8 document.
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// trimDocumentPrefix strips the UTF-8 byte order marks and whitespace that
+// some servers send before the document, in any order. gofeed skips them in
+// XML feeds but the JSON Feed parser fails on a byte order mark.
+func trimDocumentPrefix(body []byte) []byte {
+	for {
+		trimmed := bytes.TrimLeft(bytes.TrimPrefix(body, utf8BOM), " \t\r\n")
+		if len(trimmed) == len(body) {
+			return body
+		}
+		body = trimmed
+	}
+}
+
 func (f *FeedFetcher) extractItems(feed *feed) ([]*FeedItem, error) {
 	if feed == nil {
 		return nil, errors.New("feed cannot be nil")
 	}
 
-	// Determine how many items to process
-	itemCount := len(feed.data.Items)
-	if f.config.MaxItems > 0 && f.config.MaxItems < itemCount {
-		itemCount = f.config.MaxItems
+	config := f.itemConfig(feed)
+	if feed.opts.OnItems != nil {
+		return nil, f.streamItems(feed, config)
+	}
+
+	items, err := f.convertFeed(feed, config)
+	if err != nil {
+		return nil, err
 	}
+	return f.finishItems(feed, items), nil
+}
 
-	result := make([]*FeedItem, 0, itemCount)
+// itemConfig returns the configuration the items of a downloaded feed are
+// converted with: the fetch's, with MaxItemsByFeedType applied unless the
+// fetch sets its own MaxItems.
+func (f *FeedFetcher) itemConfig(feed *feed) Config {
+	config := f.configFor(feed.opts)
+	if n, ok := config.MaxItemsByFeedType[feed.data.FeedType]; ok && feed.opts.MaxItems == 0 {
+		config.MaxItems = n
+	}
+	return config
+}
 
-	for i := 0; i < itemCount; i++ {
-		item := feed.data.Items[i]
-		if item == nil {
-			continue
+// convertFeed converts the items of a downloaded feed and records the
+// dropped ones. It fails when an item's publication date format cannot be
+// parsed or, with StrictValidation, when an item is invalid rather than
+// filtered.
+func (f *FeedFetcher) convertFeed(feed *feed, config Config) ([]*FeedItem, error) {
+	items, itemErrs := convertItems(feed.parsedURL, feed.siteURL(), feed.data.Items, config)
+	feed.itemErrs = itemErrs
+	if n := len(itemErrs); n > 0 {
+		if err := f.dateFormatError(feed, itemErrs[n-1]); err != nil {
+			return nil, err
 		}
+	}
 
-		parsed, err := f.validateAndConvertItem(feed.parsedURL, item)
-		if err != nil {
-			if errors.Is(err, validation.ErrFeedPublicationDateFormat) {
-				// Do not process other items as they will all have the same error
-				return nil, validation.ErrFeedPublicationDateFormat
+	if config.StrictValidation {
+		for _, itemErr := range itemErrs {
+			if !isFiltered(itemErr.Err) {
+				return nil, newFeedError(feed.url, PhaseValidate, itemErr)
 			}
-			// Continue processing other items
-			continue
 		}
+	}
+	return items, nil
+}
 
-		if parsed != nil {
-			result = append(result, parsed)
+// dateFormatError returns the error failing the fetch when itemErr is about
+// a publication date format that cannot be parsed, after passing the date
+// to the unparseable date sink, and nil for any other error.
+func (f *FeedFetcher) dateFormatError(feed *feed, itemErr ItemError) error {
+	if !errors.Is(itemErr, ErrFeedPublicationDateFormat) {
+		return nil
+	}
+	if f.dateSink != nil {
+		raw := validation.RawPublicationDate(feed.data.Items[itemErr.Index])
+		go f.dateSink(feed.url, raw)
+	}
+	return newFeedError(feed.url, PhaseValidate, ErrFeedPublicationDateFormat)
+}
+
+// finishItems applies the fetcher's dedup cache, custom field mapper and ID
+// generator to converted items and counts the remaining ones in the feed's
+// stats. It may be called once per batch of a streamed fetch.
+func (f *FeedFetcher) finishItems(feed *feed, items []*FeedItem) []*FeedItem {
+	if f.dedupCache != nil {
+		items = slices.DeleteFunc(items, func(item *FeedItem) bool {
+			return f.dedupCache.Seen(item.ContentHash)
+		})
+	}
+	if f.customMapper != nil {
+		for _, item := range items {
+			f.customMapper(feed.data.Items[item.FeedIndex].Custom, item)
+		}
+	}
+	if f.idGenerator != nil {
+		for _, item := range items {
+			item.ID = f.idGenerator(item)
+		}
+	}
+	feed.stats.ItemsAccepted += len(items)
+	for _, item := range items {
+		if item.DuplicateGUID {
+			feed.stats.DuplicateGUIDs++
+		}
+		if f.novelty != nil {
+			feed.seenKeys = append(feed.seenKeys, uint64(URLID(item)))
 		}
 	}
 
-	return result, nil
+	return items
 }
 
-func (f *FeedFetcher) validateAndConvertItem(feedURL *url.URL, item *gofeed.Item) (*FeedItem, error) {
+func validateAndConvertItem(config Config, feedURL *url.URL, item *gofeed.Item) (*FeedItem, error) {
 	if feedURL == nil || item == nil {
 		return nil, errors.New("feedURL and item cannot be nil")
 	}
 
-	itemURL, err := validation.ValidateAndResolveURL(feedURL, item.Link)
+	link := item.Link
+	if strings.TrimSpace(link) == "" && config.UseGUIDAsURLFallback && validation.IsAbsoluteHTTPURL(item.GUID) {
+		link = item.GUID
+	}
+
+	base := feedURL
+	if !config.IgnoreXMLBase {
+		base = feedparser.ItemBase(feedURL, item)
+	}
+
+	itemURL, err := validation.ValidateAndResolveURLWithOptions(feedURL, link, validation.URLOptions{
+		RejectCrossDomain: config.RejectCrossDomainLinks,
+		SameSite:          config.AllowSameSiteLinks,
+		MaxLength:         config.MaxURLLength,
+		UnescapeEntities:  config.UnescapeLinkEntities,
+		Base:              base,
+	})
 	if err != nil {
 		return nil, err
 	}
+	resolvedURL := itemURL
+	if config.URLNormalizer != nil {
+		itemURL = config.URLNormalizer(itemURL)
+	}
 
-	publishedAt, err := validation.ValidatePublicationDate(item, f.config.MaxAge, f.config.FutureDriftTolerance)
-	if err != nil {
+	if config.DropBlockedLinks && len(config.BlockedDomains) > 0 {
+		if u, err := url.Parse(itemURL); err == nil && hostMatches(u.Hostname(), config.BlockedDomains) {
+			return nil, ErrBlockedDomain
+		}
+	}
+
+	publishedAt, err := validation.ValidatePublicationDateWithOptions(item, validation.DateOptions{
+		MaxAge:          config.MaxAge,
+		FutureTolerance: config.FutureDriftTolerance,
+		MaxDateLength:   config.MaxDateLength,
+		MinDate:         config.MinPublishDate,
+		MaxDate:         config.MaxPublishDate,
+		Location:        config.DefaultFeedTimezone,
+		DisableFallback: config.DisableDateParserFallback,
+	})
+	archived := config.KeepOldItems && errors.Is(err, ErrPublicationTooOld)
+	if err != nil && !archived {
 		return nil, err
 	}
 
-	headline, err := validation.ValidateAndSanitizeHeadline(item.Title, f.config.MaxHeadingLength)
+	headline, err := validation.ValidateAndSanitizeHeadline(item.Title, config.MaxHeadingLength)
 	if err != nil {
 		return nil, err
 	}
 
-	content := validation.ExtractContent(item)
-
-	return &FeedItem{
+	summary, content := validation.SplitContent(item)
+	if config.MinContentLength > 0 && textLength(content) < config.MinContentLength {
+		return nil, ErrContentTooShort
+	}
+	result := &FeedItem{
 		FeedURL:     feedURL.String(),
 		URL:         itemURL,
+		GUID:        strings.TrimSpace(item.GUID),
 		PublishedAt: publishedAt,
 		Headline:    headline,
-		Content:     content,
-	}, nil
+		Summary:     summary,
+		Links:       itemLinks(feedURL, base, item),
+		Podcast:     itemPodcast(feedURL, base, item),
+		Media:       itemMedia(feedURL, base, item),
+		Archived:    archived,
+		ContentHash: contentHash(resolvedURL, headline, cmp.Or(summary, content)),
+	}
+	if config.IncludeContent {
+		result.Content = content
+	}
+	if config.SummaryWords > 0 {
+		result.Summary = summarize(cmp.Or(summary, content), config.SummaryWords)
+	}
+	sourceName, sourceURL := feedparser.ItemSource(item)
+	result.SourceName = sourceName
+	result.SourceURL = resolveOptionalURL(feedURL, base, sourceURL)
+
+	if config.ExtractCanonicalURL {
+		result.CanonicalURL = canonicalURL(itemURL, content, summary)
+	}
+	if config.URLNormalizer != nil {
+		if result.CanonicalURL != "" {
+			result.CanonicalURL = config.URLNormalizer(result.CanonicalURL)
+		}
+		for i := range result.Links {
+			result.Links[i].Href = config.URLNormalizer(result.Links[i].Href)
+		}
+		if config.NormalizeContentLinks {
+			if result.Content != "" {
+				result.Content = normalizeContentLinks(result.Content, config.URLNormalizer)
+			}
+			result.Summary = normalizeContentLinks(result.Summary, config.URLNormalizer)
+		}
+	}
+
+	return result, nil
 }
@@ -1,14 +1,28 @@
 package feedfetcher
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"github.com/mmcdole/gofeed"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+	"github.com/reddot-watch/feedfetcher/internal/limiter"
 )
 
 type MockFeedParser struct {
@@ -19,12 +33,17 @@ func (m *MockFeedParser) ParseURLWithContext(url string, ctx context.Context) (*
 	return m.MockParseFn(url, ctx)
 }
 
-// NewFeedFetcherWithParser allows injecting a custom feedparser implementation
+func (m *MockFeedParser) Parse(r io.Reader) (*gofeed.Feed, error) {
+	return gofeed.NewParser().Parse(r)
+}
+
+// NewFeedFetcherWithParser allows injecting a custom feedparser implementation.
+// Rate limiting is disabled so tests can fetch the same domain repeatedly.
 func NewFeedFetcherWithParser(config Config, parser feedparser.Parser) *FeedFetcher {
-	return &FeedFetcher{
-		config: config,
-		parser: parser,
-	}
+	f := NewFeedFetcher(config)
+	f.parser = parser
+	f.rateLimiter = limiter.NewDomainRateLimiter(rate.Inf, 1)
+	return f
 }
 
 func TestFeedFetcher_FetchFeed(t *testing.T) {
@@ -96,3 +115,823 @@ func TestFeedFetcher_FetchFeed(t *testing.T) {
 func timePtr(t time.Time) *time.Time {
 	return &t
 }
+
+func TestFeedError(t *testing.T) {
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			switch url {
+			case "https://example.com/timeout":
+				return nil, context.DeadlineExceeded
+			case "https://example.com/notfound":
+				return nil, gofeed.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+			}
+			return nil, gofeed.ErrFeedTypeNotDetected
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser)
+
+	tests := []struct {
+		name      string
+		url       string
+		wantPhase Phase
+		wantErr   error
+	}{
+		{"timeout", "https://example.com/timeout", PhaseDownload, context.DeadlineExceeded},
+		{"http error", "https://example.com/notfound", PhaseDownload, nil},
+		{"unparseable body", "https://example.com/html", PhaseParse, gofeed.ErrFeedTypeNotDetected},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fetcher.download(context.Background(), &feed{url: tt.url})
+
+			var feedErr *FeedError
+			if assert.True(t, errors.As(err, &feedErr)) {
+				assert.Equal(t, tt.url, feedErr.FeedURL)
+				assert.Equal(t, tt.wantPhase, feedErr.Phase)
+			}
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("invalid publication date", func(t *testing.T) {
+		f := &feed{
+			url:       "https://example.com/feed",
+			parsedURL: mustParseURL(t, "https://example.com/feed"),
+			data: &gofeed.Feed{Items: []*gofeed.Item{
+				{Title: "Item", Link: "/item", Published: "not a date"},
+			}},
+		}
+		_, err := fetcher.extractItems(f)
+
+		var feedErr *FeedError
+		if assert.True(t, errors.As(err, &feedErr)) {
+			assert.Equal(t, PhaseValidate, feedErr.Phase)
+		}
+		assert.ErrorIs(t, err, ErrFeedPublicationDateFormat)
+	})
+}
+
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestFeedFetcher_FetchAndProcessWithOptions(t *testing.T) {
+	var gotUserAgent, gotHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserAgent = r.UserAgent()
+		gotHeader = r.Header.Get("X-Api-Key")
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, rssDocument(time.Now(), "First", "Second"))
+	}))
+	defer server.Close()
+
+	fetcher := NewDefaultFeedFetcher()
+
+	items, err := fetcher.FetchAndProcessWithOptions(context.Background(), server.URL, FetchOptions{
+		UserAgent: "CustomAgent/2.0",
+		Headers:   http.Header{"X-Api-Key": []string{"secret"}},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "CustomAgent/2.0", gotUserAgent)
+	assert.Equal(t, "secret", gotHeader)
+
+	// The overrides must not leak into subsequent fetches.
+	_, err = fetcher.FetchAndProcess(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultConfig.UserAgent, gotUserAgent)
+	assert.Empty(t, gotHeader)
+}
+
+// rssDocument renders a minimal RSS document with one item per title, all
+// published at pubDate.
+func rssDocument(pubDate time.Time, titles ...string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel>`)
+	b.WriteString(`<title>Test feed</title><link>https://example.com/</link>`)
+	for i, title := range titles {
+		fmt.Fprintf(&b, `<item><title>%s</title><link>https://example.com/%d</link><pubDate>%s</pubDate></item>`,
+			title, i, pubDate.Format(time.RFC1123Z))
+	}
+	b.WriteString(`</channel></rss>`)
+	return b.String()
+}
+
+func TestFeedFetcher_FetchOptionsOverrideConfig(t *testing.T) {
+	now := time.Now()
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{Title: "Recent", Link: "/recent", PublishedParsed: timePtr(now.Add(-time.Hour))},
+				{Title: "Older", Link: "/older", PublishedParsed: timePtr(now.Add(-48 * time.Hour))},
+				{Title: "Oldest", Link: "/oldest", PublishedParsed: timePtr(now.Add(-72 * time.Hour))},
+			}}, nil
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser)
+
+	items, err := fetcher.FetchAndProcess(context.Background(), "https://example.com/feed")
+	assert.NoError(t, err)
+	assert.Len(t, items, 1)
+
+	items, err = fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed", FetchOptions{
+		MaxAge: 7 * 24 * time.Hour,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+
+	items, err = fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed", FetchOptions{
+		MaxAge:   7 * 24 * time.Hour,
+		MaxItems: 2,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	assert.Equal(t, DefaultConfig, fetcher.config)
+}
+
+func TestFeedFetcher_FetchOptionsFutureDriftTolerance(t *testing.T) {
+	now := time.Now()
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{Title: "Now", Link: "/now", PublishedParsed: timePtr(now.Add(-time.Minute))},
+				{Title: "Soon", Link: "/soon", PublishedParsed: timePtr(now.Add(time.Hour))},
+			}}, nil
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser)
+	tolerance := func(d time.Duration) *time.Duration { return &d }
+
+	items, err := fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed", FetchOptions{})
+	require.NoError(t, err)
+	assert.Len(t, items, 2, "the fetcher allows a day")
+
+	items, err = fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed", FetchOptions{
+		FutureDriftTolerance: tolerance(0),
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 1, "zero rejects any future date")
+	assert.Equal(t, "Now", items[0].Headline)
+
+	_, err = fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed", FetchOptions{
+		FutureDriftTolerance: tolerance(-time.Second),
+	})
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestFeedFetcher_FetchAndProcessWithStats(t *testing.T) {
+	body := rssDocument(time.Now(), "First", "Second", "Third")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	items, stats, err := NewDefaultFeedFetcher().FetchAndProcessWithStats(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, items, 3)
+	assert.Equal(t, int64(len(body)), stats.BytesDownloaded)
+	assert.Equal(t, 3, stats.ItemsFound)
+	assert.Equal(t, 3, stats.ItemsAccepted)
+	assert.Positive(t, stats.Duration)
+}
+
+func TestFeedFetcher_WithUserAgentPool(t *testing.T) {
+	body := rssDocument(time.Now(), "First")
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	fetcher := NewDefaultFeedFetcher().WithUserAgentPool([]string{"agent-a", "", "agent-b"})
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := fetcher.FetchAndProcess(ctx, server.URL)
+		require.NoError(t, err)
+	}
+	_, err := fetcher.FetchAndProcessWithOptions(ctx, server.URL, FetchOptions{UserAgent: "explicit"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"agent-a", "agent-b", "agent-a", "explicit"}, got)
+}
+
+func TestFeedFetcher_WithUserAgentForDomain(t *testing.T) {
+	body := rssDocument(time.Now(), "First")
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("User-Agent"))
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	localhostURL := "http://localhost:" + port + "/"
+
+	base := NewDefaultFeedFetcher().WithUserAgentPool([]string{"pooled"})
+	fetcher := base.WithUserAgentForDomain("127.0.0.1", "browser")
+	ctx := context.Background()
+
+	for _, feedURL := range []string{server.URL, localhostURL} {
+		_, err := fetcher.FetchAndProcess(ctx, feedURL)
+		require.NoError(t, err)
+	}
+	_, err = fetcher.FetchAndProcessWithOptions(ctx, server.URL, FetchOptions{UserAgent: "explicit"})
+	require.NoError(t, err)
+	_, err = base.FetchAndProcess(ctx, server.URL)
+	require.NoError(t, err)
+	_, err = fetcher.WithUserAgentForDomain("127.0.0.1", "").FetchAndProcess(ctx, server.URL)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"browser", "pooled", "explicit", "pooled", "pooled"}, got)
+	assert.Len(t, base.domainUAs, 0, "copies are independent")
+}
+
+func TestFeedFetcher_FetchThenParse(t *testing.T) {
+	const body = "<html><body>not a feed</body></html>"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	var hookCalled bool
+	fetcher := NewDefaultFeedFetcher().WithResponseHook(func(string, []byte, http.Header) {
+		hookCalled = true
+	})
+
+	_, stats, err := fetcher.FetchAndProcessWithStats(context.Background(), server.URL)
+	var feedErr *FeedError
+	require.ErrorAs(t, err, &feedErr)
+	assert.Equal(t, PhaseParse, feedErr.Phase)
+	assert.True(t, hookCalled, "the hook runs after the download, before parsing")
+	assert.Equal(t, int64(len(body)), stats.BytesDownloaded)
+}
+
+func TestFeedFetcher_WithParseTimeout(t *testing.T) {
+	body := rssDocument(time.Now(), "First", "Second")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/rss+xml")
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	fetcher := NewDefaultFeedFetcher().
+		WithRequestTimeout(5 * time.Second).
+		WithParseTimeout(100 * time.Millisecond)
+
+	// The slow download must count against RequestTimeout, not ParseTimeout
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2)
+
+	_, err = fetcher.WithRequestTimeout(50*time.Millisecond).FetchAndProcess(context.Background(), server.URL)
+	var feedErr *FeedError
+	require.ErrorAs(t, err, &feedErr)
+	assert.Equal(t, PhaseDownload, feedErr.Phase)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestFeedFetcher_LatencyStats(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "First"))
+	}))
+	defer server.Close()
+
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, nil)
+	fetcher.parser = fetcher.newParser()
+
+	host := mustParseURL(t, server.URL).Host
+	_, ok := fetcher.LatencyStats(host)
+	assert.False(t, ok)
+
+	_, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	stats, ok := fetcher.LatencyStats(host)
+	assert.True(t, ok)
+	assert.Equal(t, 1, stats.Samples)
+	assert.Positive(t, stats.EMA)
+	assert.Equal(t, stats.EMA, stats.P95)
+}
+
+func TestFeedFetcher_DedupHeadlines(t *testing.T) {
+	now := time.Now()
+	mockFeed := &gofeed.Feed{Items: []*gofeed.Item{
+		{Title: "Breaking News", Link: "/a", PublishedParsed: timePtr(now)},
+		{Title: "breaking  news ", Link: "/a?utm_source=rss", PublishedParsed: timePtr(now)},
+		{Title: "Other story", Link: "/b", PublishedParsed: timePtr(now)},
+	}}
+
+	tests := []struct {
+		name      string
+		dedup     bool
+		wantLinks []string
+	}{
+		{"disabled", false, []string{"https://example.com/a", "https://example.com/a?utm_source=rss", "https://example.com/b"}},
+		{"enabled", true, []string{"https://example.com/a", "https://example.com/b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.DedupHeadlines = tt.dedup
+			fetcher := NewFeedFetcherWithParser(config, nil)
+
+			items, err := fetcher.extractItems(&feed{
+				url:       "https://example.com/feed",
+				parsedURL: mustParseURL(t, "https://example.com/feed"),
+				data:      mockFeed,
+			})
+			assert.NoError(t, err)
+
+			var links []string
+			for _, item := range items {
+				links = append(links, item.URL)
+			}
+			assert.Equal(t, tt.wantLinks, links)
+		})
+	}
+}
+
+func TestFeedFetcher_WithResponseHook(t *testing.T) {
+	body := rssDocument(time.Now(), "First", "Second")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Header().Set("ETag", `"v1"`)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	var gotURL, gotETag string
+	var gotBody []byte
+	fetcher := NewDefaultFeedFetcher().WithResponseHook(func(url string, body []byte, header http.Header) {
+		gotURL = url
+		gotBody = body
+		gotETag = header.Get("ETag")
+	})
+
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	assert.NoError(t, err)
+	assert.Len(t, items, 2, "hook must not consume the body needed for parsing")
+	assert.Equal(t, server.URL, gotURL)
+	assert.Equal(t, body, string(gotBody))
+	assert.Equal(t, `"v1"`, gotETag)
+}
+
+func TestFeedFetcher_WithOnFetchComplete(t *testing.T) {
+	body := rssDocument(time.Now(), "First", "Second")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	type outcome struct {
+		url   string
+		stats FetchStats
+		err   error
+	}
+	var outcomes []outcome
+	fetcher := NewDefaultFeedFetcher().WithOnFetchComplete(func(url string, stats FetchStats, err error) {
+		outcomes = append(outcomes, outcome{url, stats, err})
+	})
+
+	_, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	_, err = fetcher.FetchAndProcess(context.Background(), server.URL+"/missing")
+	require.Error(t, err)
+	_, err = fetcher.FetchAndProcess(context.Background(), "://bad")
+	require.Error(t, err)
+
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, server.URL, outcomes[0].url)
+	assert.NoError(t, outcomes[0].err)
+	assert.Equal(t, 2, outcomes[0].stats.ItemsAccepted)
+	assert.Equal(t, int64(len(body)), outcomes[0].stats.BytesDownloaded)
+
+	assert.Equal(t, server.URL+"/missing", outcomes[1].url)
+	var feedErr *FeedError
+	require.ErrorAs(t, outcomes[1].err, &feedErr)
+	assert.Equal(t, PhaseDownload, feedErr.Phase)
+	assert.Equal(t, err, outcomes[2].err, "hook gets the error returned to the caller")
+	assert.Zero(t, outcomes[2].stats)
+}
+
+func TestFeedFetcher_WithCustomFieldMapper(t *testing.T) {
+	pubDate := time.Now().Format(time.RFC1123Z)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0"?><rss version="2.0"><channel><title>Wire</title>
+			<item><title>Urgent</title><link>https://example.com/1</link><pubDate>%[1]s</pubDate><priority>1</priority></item>
+			<item><title>Dropped</title><link>https://example.com/2</link></item>
+			<item><title>Routine</title><link>https://example.com/3</link><pubDate>%[1]s</pubDate></item>
+			</channel></rss>`, pubDate)
+	}))
+	defer server.Close()
+
+	priorities := map[string]string{}
+	fetcher := NewDefaultFeedFetcher().WithCustomFieldMapper(func(custom map[string]string, item *FeedItem) {
+		priorities[item.Headline] = custom["priority"]
+		if custom["priority"] == "1" {
+			item.Headline = "URGENT: " + item.Headline
+		}
+	}).WithIDGenerator(func(item *FeedItem) int64 {
+		return int64(len(item.Headline))
+	})
+
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, map[string]string{"Urgent": "1", "Routine": ""}, priorities, "only accepted items are mapped")
+	assert.Equal(t, "URGENT: Urgent", items[0].Headline)
+	assert.Equal(t, int64(len("URGENT: Urgent")), items[0].ID, "mapper runs before the ID generator")
+}
+
+func TestFeedFetcher_SlowFetchThreshold(t *testing.T) {
+	body := rssDocument(time.Now(), "First")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		threshold time.Duration
+		wantWarn  bool
+	}{
+		{"disabled", 0, false},
+		{"exceeded", 10 * time.Millisecond, true},
+		{"not exceeded", time.Minute, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			config := DefaultConfig
+			config.SlowFetchThreshold = tt.threshold
+			fetcher := NewFeedFetcher(config).WithLogger(zerolog.New(&logs).Level(zerolog.WarnLevel))
+
+			items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+			require.NoError(t, err)
+			assert.Len(t, items, 1)
+			assert.Equal(t, tt.wantWarn, strings.Contains(logs.String(), "slow feed response"), logs.String())
+			if tt.wantWarn {
+				assert.Contains(t, logs.String(), `"duration":`)
+			}
+		})
+	}
+}
+
+func TestValidateAndConvertItem_GUIDFallback(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		fallback bool
+		item     *gofeed.Item
+		wantURL  string
+		wantErr  error
+	}{
+		{
+			name:     "guid used as url",
+			fallback: true,
+			item:     &gofeed.Item{Title: "Post", GUID: "https://micro.example.com/posts/1", PublishedParsed: &now},
+			wantURL:  "https://micro.example.com/posts/1",
+		},
+		{
+			name:     "link preferred over guid",
+			fallback: true,
+			item:     &gofeed.Item{Title: "Post", Link: "/posts/1", GUID: "https://micro.example.com/posts/1", PublishedParsed: &now},
+			wantURL:  "https://example.com/posts/1",
+		},
+		{
+			name:     "fallback disabled",
+			fallback: false,
+			item:     &gofeed.Item{Title: "Post", GUID: "https://micro.example.com/posts/1", PublishedParsed: &now},
+			wantErr:  ErrInvalidURL,
+		},
+		{
+			name:     "guid is not a url",
+			fallback: true,
+			item:     &gofeed.Item{Title: "Post", GUID: "tag:example.com,2025:1", PublishedParsed: &now},
+			wantErr:  ErrInvalidURL,
+		},
+		{
+			name:     "neither link nor guid",
+			fallback: true,
+			item:     &gofeed.Item{Title: "Post", PublishedParsed: &now},
+			wantErr:  ErrInvalidURL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.UseGUIDAsURLFallback = tt.fallback
+
+			got, err := validateAndConvertItem(config, feedURL, tt.item)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantURL, got.URL)
+		})
+	}
+}
+
+func TestValidateAndConvertItem_IncludeContent(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	item := &gofeed.Item{
+		Title: "Story", Link: "/a", Description: "Summary", Content: "<p>The full body</p>",
+		PublishedParsed: timePtr(time.Now()),
+	}
+
+	full, err := validateAndConvertItem(DefaultConfig, feedURL, item)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>The full body</p>", full.Content)
+
+	config := DefaultConfig
+	config.IncludeContent = false
+	config.URLNormalizer = StripTrackingParams
+	config.NormalizeContentLinks = true
+	slim, err := validateAndConvertItem(config, feedURL, item)
+	require.NoError(t, err)
+	assert.Empty(t, slim.Content)
+	assert.Equal(t, "Summary", slim.Summary)
+	assert.Equal(t, full.ContentHash, slim.ContentHash, "the hash does not depend on IncludeContent")
+
+	config.MinContentLength = 20
+	_, err = validateAndConvertItem(config, feedURL, item)
+	assert.ErrorIs(t, err, ErrContentTooShort, "filters still see the content")
+}
+
+func TestFeedFetcher_MaxRateLimitWait(t *testing.T) {
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{}, nil
+		},
+	}
+	config := DefaultConfig
+	config.MaxRateLimitWait = 10 * time.Millisecond
+	fetcher := NewFeedFetcherWithParser(config, mockParser)
+	fetcher.rateLimiter = limiter.NewDomainRateLimiter(rate.Every(time.Hour), 1)
+
+	ctx := context.Background()
+	_, err := fetcher.FetchAndProcess(ctx, "https://example.com/a")
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = fetcher.FetchAndProcess(ctx, "https://example.com/b")
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Less(t, time.Since(start), time.Second)
+
+	_, err = fetcher.FetchAndProcess(ctx, "https://other.com/a")
+	assert.NoError(t, err, "other domains are not affected")
+}
+
+func TestFeedFetcher_MaxRateLimitWait_NotRetriedOrCounted(t *testing.T) {
+	var calls int
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			calls++
+			return &gofeed.Feed{}, nil
+		},
+	}
+	config := DefaultConfig
+	config.MaxRateLimitWait = 10 * time.Millisecond
+	config.MaxRetries = 2
+	config.RetryBackoff = 50 * time.Millisecond
+	fetcher := NewFeedFetcherWithParser(config, mockParser).WithCircuitBreaker(2, time.Hour)
+	fetcher.rateLimiter = limiter.NewDomainRateLimiter(rate.Every(time.Hour), 1)
+
+	ctx := context.Background()
+	_, err := fetcher.FetchAndProcess(ctx, "https://example.com/a")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		_, err = fetcher.FetchAndProcess(ctx, "https://example.com/b")
+		assert.ErrorIs(t, err, ErrRateLimited, "rate limiting alone never opens the circuit")
+		assert.Less(t, time.Since(start), 50*time.Millisecond, "not retried with backoff")
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestFeedFetcher_DublinCoreDateOnly(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	doc := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/">
+  <title>Dublin Core feed</title>
+  <entry>
+    <title>Dated by dc:date only</title>
+    <link href="https://example.com/a"/>
+    <dc:date>` + recent.Format(time.RFC3339) + `</dc:date>
+  </entry>
+</feed>`
+
+	items, err := NewDefaultFeedFetcher().processReader("https://example.com/feed", strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.True(t, items[0].PublishedAt.Equal(recent))
+}
+
+func TestFeedFetcher_WithUnparseableDateSink(t *testing.T) {
+	type report struct{ feedURL, raw string }
+	reports := make(chan report, 2)
+	fetcher := NewDefaultFeedFetcher().WithUnparseableDateSink(func(feedURL, raw string) {
+		reports <- report{feedURL, raw}
+	})
+
+	doc := `<rss version="2.0"><channel><title>t</title>
+<item><title>Missing</title><link>https://example.com/a</link></item>
+<item><title>Broken</title><link>https://example.com/b</link><pubDate>the day before yesterday</pubDate></item>
+</channel></rss>`
+
+	_, err := fetcher.processReader("https://example.com/feed", strings.NewReader(doc))
+	assert.ErrorIs(t, err, ErrFeedPublicationDateFormat)
+
+	select {
+	case got := <-reports:
+		assert.Equal(t, report{"https://example.com/feed", "the day before yesterday"}, got)
+	case <-time.After(time.Second):
+		t.Fatal("sink was not called")
+	}
+
+	select {
+	case got := <-reports:
+		t.Fatalf("unexpected report %v; missing dates must not be reported", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestFeedFetcher_ConcurrentUse is meant to be run with -race: one fetcher
+// serves many goroutines, both through a mock returning the same parsed feed
+// every time and through the real parser.
+func TestFeedFetcher_ConcurrentUse(t *testing.T) {
+	pubDate := time.Now().Add(-time.Hour).Format(time.RFC1123Z)
+	shared := &gofeed.Feed{Items: []*gofeed.Item{
+		{Title: "First", Link: "/a", Published: pubDate},
+		{Title: "Second", Link: "/b", Published: pubDate},
+	}}
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return shared, nil
+		},
+	}
+	mocked := NewFeedFetcherWithParser(DefaultConfig, mockParser)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "First", "Second"))
+	}))
+	defer server.Close()
+	live := NewFeedFetcherWithParser(DefaultConfig, nil).WithResponseHook(func(string, []byte, http.Header) {})
+	live.parser = live.newParser()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			items, err := mocked.FetchAndProcess(context.Background(), fmt.Sprintf("https://feed%d.example.com/rss", i))
+			assert.NoError(t, err)
+			assert.Len(t, items, 2)
+		}(i)
+		go func() {
+			defer wg.Done()
+			items, err := live.FetchAndProcess(context.Background(), server.URL)
+			assert.NoError(t, err)
+			assert.Len(t, items, 2)
+		}()
+	}
+	wg.Wait()
+
+	for _, item := range shared.Items {
+		assert.Nil(t, item.PublishedParsed, "parsed items must not be modified")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, DefaultConfig.Validate())
+
+	config := DefaultConfig
+	config.FutureDriftTolerance = 0
+	assert.NoError(t, config.Validate())
+
+	config.FutureDriftTolerance = -time.Hour
+	assert.ErrorIs(t, config.Validate(), ErrInvalidConfig)
+
+	fetcher := NewFeedFetcherWithParser(config, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			t.Fatal("invalid configuration must fail before fetching")
+			return nil, nil
+		},
+	})
+	_, err := fetcher.FetchAndProcess(context.Background(), "https://example.com/feed")
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+}
+
+func TestFeedFetcher_CircuitBreaker(t *testing.T) {
+	var calls int
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			calls++
+			return nil, gofeed.HTTPError{StatusCode: 503, Status: "503 Service Unavailable"}
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser).WithCircuitBreaker(2, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		_, err := fetcher.FetchAndProcess(context.Background(), "https://feeds.example.com/rss")
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+
+	_, err := fetcher.FetchAndProcess(context.Background(), "https://www.example.com/atom")
+	assert.ErrorIs(t, err, ErrCircuitOpen, "circuit is keyed by registrable domain")
+	assert.Equal(t, 2, calls, "no fetch is attempted while the circuit is open")
+
+	_, err = fetcher.FetchAndProcess(context.Background(), "https://example.org/rss")
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestFeedFetcher_WithDefaultTimezone(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	local := time.Now().Add(-2 * time.Hour).In(loc).Truncate(time.Second)
+	body := `<?xml version="1.0"?><rss version="2.0"><channel><title>Regional</title>` +
+		`<item><title>Local time</title><link>https://example.com/a</link>` +
+		`<pubDate>` + local.Format("Mon, 02 Jan 2006 15:04:05") + `</pubDate></item></channel></rss>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	items, err := NewDefaultFeedFetcher().WithDefaultTimezone(loc).FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.True(t, items[0].PublishedAt.Equal(local), "got %v, want %v", items[0].PublishedAt, local.UTC())
+
+	items, err = NewDefaultFeedFetcher().FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, time.Hour, items[0].PublishedAt.Sub(local), "read as UTC by default")
+}
+
+func TestTrimDocumentPrefix(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"clean", "<rss/>", "<rss/>"},
+		{"bom", "\xef\xbb\xbf<rss/>", "<rss/>"},
+		{"blank lines", "\r\n\n  \t<?xml?>", "<?xml?>"},
+		{"bom then whitespace", "\xef\xbb\xbf\n\n<rss/>", "<rss/>"},
+		{"whitespace then bom", "  \xef\xbb\xbf{}", "{}"},
+		{"trailing whitespace kept", "<rss/>\n", "<rss/>\n"},
+		{"only whitespace", " \n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(trimDocumentPrefix([]byte(tt.input))))
+		})
+	}
+}
+
+func TestFeedFetcher_DocumentPrefix(t *testing.T) {
+	published := time.Now().UTC().Format(time.RFC3339)
+	documents := map[string]string{
+		"rss": "\xef\xbb\xbf\r\n\r\n" + rssDocument(time.Now(), "Prefixed"),
+		"json feed": "\xef\xbb\xbf" + `{"version":"https://jsonfeed.org/version/1.1","title":"JSON",
+			"items":[{"id":"1","url":"https://example.com/1","title":"Prefixed","date_published":"` + published + `"}]}`,
+	}
+
+	for name, doc := range documents {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, doc)
+			}))
+			defer server.Close()
+
+			items, err := NewDefaultFeedFetcher().FetchAndProcess(context.Background(), server.URL)
+			require.NoError(t, err)
+			require.Len(t, items, 1)
+			assert.Equal(t, "Prefixed", items[0].Headline)
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package feedfetcher
+
+import "time"
+
+// UnknownDay is the GroupByDay key for items without a publication date.
+const UnknownDay = "unknown"
+
+// GroupByDay buckets items by their UTC publication date, keyed as
+// YYYY-MM-DD. Items with a zero PublishedAt are grouped under UnknownDay.
+// Items keep their relative order within each bucket.
+func GroupByDay(items []*FeedItem) map[string][]*FeedItem {
+	return GroupByDayIn(items, time.UTC)
+}
+
+// GroupByDayIn is like GroupByDay but uses the calendar date in loc, so an
+// item published late in the evening lands on the day a reader in loc saw it.
+func GroupByDayIn(items []*FeedItem, loc *time.Location) map[string][]*FeedItem {
+	groups := make(map[string][]*FeedItem)
+	for _, item := range items {
+		if item == nil {
+			continue
+		}
+
+		key := UnknownDay
+		if !item.PublishedAt.IsZero() {
+			key = item.PublishedAt.In(loc).Format(time.DateOnly)
+		}
+		groups[key] = append(groups[key], item)
+	}
+	return groups
+}
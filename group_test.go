@@ -0,0 +1,30 @@
+package feedfetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByDay(t *testing.T) {
+	late := &FeedItem{Headline: "Late", PublishedAt: time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)}
+	early := &FeedItem{Headline: "Early", PublishedAt: time.Date(2024, 3, 1, 1, 0, 0, 0, time.UTC)}
+	next := &FeedItem{Headline: "Next", PublishedAt: time.Date(2024, 3, 2, 12, 0, 0, 0, time.UTC)}
+	undated := &FeedItem{Headline: "Undated"}
+	items := []*FeedItem{late, early, next, undated, nil}
+
+	groups := GroupByDay(items)
+	assert.Equal(t, map[string][]*FeedItem{
+		"2024-03-01": {late, early},
+		"2024-03-02": {next},
+		UnknownDay:   {undated},
+	}, groups)
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	groups = GroupByDayIn(items, tokyo)
+	assert.Equal(t, []*FeedItem{early}, groups["2024-03-01"])
+	assert.Equal(t, []*FeedItem{late, next}, groups["2024-03-02"], "23:30 UTC is the next morning in Tokyo")
+}
@@ -0,0 +1,44 @@
+package feedfetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+)
+
+// ContentHash returns the hex SHA-256 digest of the item's URL, Headline and
+// SummaryOrContent, in that order, each followed by a NUL byte so that
+// moving text between fields changes the hash. SummaryOrContent is the
+// description when the item has one, which is what Content held before
+// Summary was split out of it. No other fields are included, and the
+// definition will not change, so stored hashes remain comparable across
+// releases.
+//
+// FeedItem.ContentHash holds this value for fetched items as converted from
+// the feed, before URLNormalizer, NormalizeContentLinks, SummaryWords or
+// IncludeContent rewrite them, so turning those settings on or off leaves the
+// hashes alone. For an item they did change, ContentHash gives a different
+// value than the field.
+func ContentHash(item *FeedItem) string {
+	return contentHash(item.URL, item.Headline, item.SummaryOrContent())
+}
+
+func contentHash(fields ...string) string {
+	h := sha256.New()
+	for _, field := range fields {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// URLID returns a 64-bit FNV-1a hash of the item's URL, for use with
+// WithIDGenerator. Items with the same URL get the same ID across fetches and
+// feeds; combine it with a URLNormalizer so that tracking parameters do not
+// produce different IDs for one article. Like ContentHash, its definition
+// will not change.
+func URLID(item *FeedItem) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(item.URL))
+	return int64(h.Sum64())
+}
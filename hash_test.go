@@ -0,0 +1,100 @@
+package feedfetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentHash(t *testing.T) {
+	item := &FeedItem{URL: "https://example.com/a", Headline: "Title", Content: "Body"}
+
+	// Pinned so that an accidental change to the definition is caught
+	assert.Equal(t, "4617cc2a0a4055302d1972d1498c8a8f7ac734374b08cfdc9735510cf95b29db", ContentHash(item))
+
+	shifted := &FeedItem{URL: "https://example.com/a", Headline: "TitleBody"}
+	assert.NotEqual(t, ContentHash(item), ContentHash(shifted), "field boundaries are part of the hash")
+
+	dated := *item
+	dated.PublishedAt = time.Now()
+	dated.ID = 42
+	assert.Equal(t, ContentHash(item), ContentHash(&dated), "only URL, headline and content are hashed")
+
+	split := *item
+	split.Summary = "Body"
+	split.Content = "The full body"
+	assert.Equal(t, ContentHash(item), ContentHash(&split), "the description is hashed, as before the split")
+}
+
+func TestValidateAndConvertItem_ContentHash(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	item := &gofeed.Item{Title: "Story", Link: "/a", Content: "Body", PublishedParsed: timePtr(time.Now())}
+
+	got, err := validateAndConvertItem(DefaultConfig, feedURL, item)
+	require.NoError(t, err)
+	assert.Equal(t, ContentHash(got), got.ContentHash)
+	assert.Len(t, got.ContentHash, 64)
+
+	both := *item
+	both.Description = "Summary"
+	got, err = validateAndConvertItem(DefaultConfig, feedURL, &both)
+	require.NoError(t, err)
+	assert.Equal(t, ContentHash(&FeedItem{URL: got.URL, Headline: got.Headline, Content: "Summary"}), got.ContentHash)
+}
+
+func TestURLID(t *testing.T) {
+	item := &FeedItem{URL: "https://example.com/a", Headline: "Title"}
+
+	// Pinned so that an accidental change to the definition is caught
+	assert.Equal(t, int64(318147211462398415), URLID(item))
+	assert.Equal(t, URLID(item), URLID(&FeedItem{URL: "https://example.com/a", Headline: "Edited"}))
+	assert.NotEqual(t, URLID(item), URLID(&FeedItem{URL: "https://example.com/b"}))
+}
+
+func TestFeedFetcher_WithIDGenerator(t *testing.T) {
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{Title: "One", Link: "/one", PublishedParsed: timePtr(time.Now())},
+				{Title: "Two", Link: "/two", PublishedParsed: timePtr(time.Now())},
+			}}, nil
+		},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, mockParser)
+
+	items, err := fetcher.FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Zero(t, items[0].ID, "unset without a generator")
+
+	items, err = fetcher.WithIDGenerator(URLID).FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	for _, item := range items {
+		assert.Equal(t, URLID(item), item.ID)
+	}
+	assert.NotEqual(t, items[0].ID, items[1].ID)
+}
+
+func TestValidateAndConvertItem_ContentHashIgnoresRewrites(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	item := &gofeed.Item{
+		Title: "Story", Link: "/a?utm_source=rss", PublishedParsed: timePtr(time.Now()),
+		Description: `<p>A <a href="https://example.com/b?utm_source=rss">long</a> summary of the story</p>`,
+	}
+
+	plain, err := validateAndConvertItem(DefaultConfig, feedURL, item)
+	require.NoError(t, err)
+
+	config := DefaultConfig
+	config.URLNormalizer = StripTrackingParams
+	config.NormalizeContentLinks = true
+	config.SummaryWords = 2
+	rewritten, err := validateAndConvertItem(config, feedURL, item)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", rewritten.URL)
+	assert.Equal(t, plain.ContentHash, rewritten.ContentHash)
+}
@@ -0,0 +1,57 @@
+package feedfetcher
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// WithHeader returns a new FeedFetcher that sends the given header with every
+// request, replacing any value set for the same key by an earlier call.
+// Headers in FetchOptions take precedence for a single fetch; User-Agent is
+// controlled by WithUserAgent and cannot be set this way.
+func (f *FeedFetcher) WithHeader(key, value string) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.headers = f.headers.Clone()
+	if newFetcher.headers == nil {
+		newFetcher.headers = make(http.Header)
+	}
+	newFetcher.headers.Set(key, value)
+	return &newFetcher
+}
+
+// WithReferer returns a new FeedFetcher that sends referer as the Referer
+// header of every request, for feeds that refuse requests without one. It is
+// shorthand for WithHeader("Referer", referer).
+func (f *FeedFetcher) WithReferer(referer string) *FeedFetcher {
+	return f.WithHeader("Referer", referer)
+}
+
+// WithOriginReferer returns a new FeedFetcher that sends the origin of each
+// feed (e.g. https://example.com/) as its Referer, unless a Referer is set
+// through WithReferer, WithHeader or FetchOptions.
+func (f *FeedFetcher) WithOriginReferer() *FeedFetcher {
+	newFetcher := *f
+	newFetcher.originReferer = true
+	return &newFetcher
+}
+
+// requestHeader merges the fetcher's headers with those of a single fetch,
+// the latter winning key by key, and adds the origin Referer if enabled.
+func (f *FeedFetcher) requestHeader(feedURL *url.URL, opts http.Header) http.Header {
+	if len(f.headers) == 0 && !f.originReferer {
+		return opts
+	}
+
+	header := f.headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	for key, values := range opts {
+		header[http.CanonicalHeaderKey(key)] = values
+	}
+	if f.originReferer && header.Get("Referer") == "" {
+		origin := url.URL{Scheme: feedURL.Scheme, Host: feedURL.Host, Path: "/"}
+		header.Set("Referer", origin.String())
+	}
+	return header
+}
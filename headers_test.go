@@ -0,0 +1,73 @@
+package feedfetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestFeedFetcher_Referer(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		if r.Header.Get("Referer") == "" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		fmt.Fprint(w, rssDocument(time.Now(), "Referred"))
+	}))
+	defer server.Close()
+	feedURL := server.URL + "/feeds/news.xml"
+
+	base := NewDefaultFeedFetcher()
+	base.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+	_, err := base.FetchAndProcess(context.Background(), feedURL)
+	require.Error(t, err, "server rejects requests without a Referer")
+
+	tests := []struct {
+		name    string
+		fetcher *FeedFetcher
+		opts    FetchOptions
+		want    string
+	}{
+		{"explicit", base.WithReferer("https://example.com/news"), FetchOptions{}, "https://example.com/news"},
+		{"origin", base.WithOriginReferer(), FetchOptions{}, server.URL + "/"},
+		{"explicit beats origin", base.WithOriginReferer().WithReferer("https://example.com/"), FetchOptions{}, "https://example.com/"},
+		{"via WithHeader", base.WithHeader("Referer", "https://example.org/"), FetchOptions{}, "https://example.org/"},
+		{
+			"fetch options win",
+			base.WithReferer("https://example.com/news"),
+			FetchOptions{Headers: http.Header{"referer": {"https://example.net/"}}},
+			"https://example.net/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, err := tt.fetcher.FetchAndProcessWithOptions(context.Background(), feedURL, tt.opts)
+			require.NoError(t, err)
+			assert.Len(t, items, 1)
+			assert.Equal(t, []string{tt.want}, got.Values("Referer"))
+		})
+	}
+
+	t.Run("other headers compose", func(t *testing.T) {
+		fetcher := base.WithHeader("X-Api-Key", "secret").WithOriginReferer()
+		_, err := fetcher.FetchAndProcess(context.Background(), feedURL)
+		require.NoError(t, err)
+		assert.Equal(t, "secret", got.Get("X-Api-Key"))
+		assert.Equal(t, server.URL+"/", got.Get("Referer"))
+	})
+
+	t.Run("copies are independent", func(t *testing.T) {
+		_ = base.WithHeader("X-Api-Key", "secret")
+		assert.Empty(t, base.headers)
+	})
+}
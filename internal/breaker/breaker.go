@@ -0,0 +1,86 @@
+// Package breaker implements a per-key circuit breaker.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker counts consecutive failures per key. Once a key reaches the failure
+// threshold its circuit opens and calls are rejected until the cooldown has
+// passed. The circuit is then half-open: a single call is let through as a
+// probe while the others are still rejected, and its success closes the
+// circuit again while its failure reopens it for a further cooldown. A probe
+// that reports neither within a cooldown is given up on and another is let
+// through.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	mu        sync.Mutex
+	states    map[string]*state
+	now       func() time.Time
+}
+
+type state struct {
+	failures  int
+	openUntil time.Time
+	probeAt   time.Time // When the half-open probe was let through; zero if none is out
+}
+
+// New creates a Breaker that opens after threshold consecutive failures and
+// stays open for cooldown.
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		states:    make(map[string]*state),
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call for key may proceed.
+func (b *Breaker) Allow(key string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.states[key]
+	if !ok || s.failures < b.threshold {
+		return true
+	}
+	now := b.now()
+	if now.Before(s.openUntil) {
+		return false
+	}
+	if !s.probeAt.IsZero() && now.Before(s.probeAt.Add(b.cooldown)) {
+		return false // a probe is already out
+	}
+	s.probeAt = now
+	return true
+}
+
+// Success records a successful call, closing the circuit for key.
+func (b *Breaker) Success(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.states, key)
+}
+
+// Failure records a failed call for key, opening its circuit once the
+// threshold is reached.
+func (b *Breaker) Failure(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s, ok := b.states[key]
+	if !ok {
+		s = &state{}
+		b.states[key] = s
+	}
+
+	s.failures++
+	s.probeAt = time.Time{}
+	if s.failures >= b.threshold {
+		s.openUntil = b.now().Add(b.cooldown)
+	}
+}
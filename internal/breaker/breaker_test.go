@@ -0,0 +1,61 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Now()
+	b := New(3, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure("example.com")
+	b.Failure("example.com")
+	assert.True(t, b.Allow("example.com"), "below threshold")
+
+	b.Failure("example.com")
+	assert.False(t, b.Allow("example.com"), "threshold reached")
+	assert.True(t, b.Allow("other.com"), "other keys are unaffected")
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow("example.com"), "cooldown passed")
+	assert.False(t, b.Allow("example.com"), "only one probe while half-open")
+
+	b.Failure("example.com")
+	assert.False(t, b.Allow("example.com"), "failure after cooldown reopens")
+
+	now = now.Add(time.Minute)
+	b.Success("example.com")
+	b.Failure("example.com")
+	assert.True(t, b.Allow("example.com"), "success resets the failure count")
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	now := time.Now()
+	b := New(1, time.Minute)
+	b.now = func() time.Time { return now }
+
+	b.Failure("example.com")
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow("example.com"), "probe")
+	assert.False(t, b.Allow("example.com"))
+
+	b.Success("example.com")
+	assert.True(t, b.Allow("example.com"), "a successful probe closes the circuit")
+	assert.True(t, b.Allow("example.com"))
+
+	b.Failure("example.com")
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow("example.com"), "probe")
+	b.Failure("example.com")
+	assert.False(t, b.Allow("example.com"), "a failed probe reopens the circuit")
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow("example.com"), "next probe after the cooldown")
+
+	now = now.Add(time.Minute)
+	assert.True(t, b.Allow("example.com"), "a probe that never reported is given up on")
+	assert.False(t, b.Allow("example.com"))
+}
@@ -2,6 +2,7 @@
 package dateparser
 
 import (
+	"errors"
 	"fmt"
 	"regexp"
 	"strings"
@@ -212,10 +213,64 @@ var dateLayouts = []string{
 	"2 Jan 2006 15:04",     // Without timezone
 }
 
+// Layouts returns a copy of the layouts ParseDate tries, in order.
+func Layouts() []string {
+	layouts := make([]string, len(dateLayouts))
+	copy(layouts, dateLayouts)
+	return layouts
+}
+
+// DefaultMaxLength bounds the input ParseDate accepts. Real dates are far
+// shorter, so longer strings are rejected before trying any layout to keep
+// the work per call bounded on hostile feeds.
+const DefaultMaxLength = 64
+
+// ErrDateTooLong is returned for inputs exceeding the maximum date length.
+var ErrDateTooLong = errors.New("date string exceeds maximum length")
+
 // ParseDate attempts to parse a date string using all available layouts
 func ParseDate(dateStr string) (time.Time, error) {
-	var t time.Time
-	var err error
+	return ParseDateWithMaxLength(dateStr, DefaultMaxLength)
+}
+
+// ParseDateWithMaxLength is like ParseDate but rejects inputs longer than
+// maxLength bytes. A maxLength of zero or less uses DefaultMaxLength.
+func ParseDateWithMaxLength(dateStr string, maxLength int) (time.Time, error) {
+	return parseDate(dateStr, maxLength, time.Parse)
+}
+
+// ParseDateWithLocation is like ParseDate but interprets dates without zone
+// information in loc rather than UTC. A nil loc means UTC.
+func ParseDateWithLocation(dateStr string, loc *time.Location) (time.Time, error) {
+	return ParseDateWithLocationMaxLength(dateStr, loc, DefaultMaxLength)
+}
+
+// ParseDateWithLocationMaxLength is like ParseDateWithLocation but rejects
+// inputs longer than maxLength bytes.
+func ParseDateWithLocationMaxLength(dateStr string, loc *time.Location, maxLength int) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return parseDate(dateStr, maxLength, func(layout, value string) (time.Time, error) {
+		return time.ParseInLocation(layout, value, loc)
+	})
+}
+
+// parseDate tries every layout on dateStr with parse.
+func parseDate(dateStr string, maxLength int, parse func(layout, value string) (time.Time, error)) (t time.Time, err error) {
+	if maxLength <= 0 {
+		maxLength = DefaultMaxLength
+	}
+	if len(dateStr) > maxLength {
+		return time.Time{}, fmt.Errorf("%w: %d bytes exceeds %d", ErrDateTooLong, len(dateStr), maxLength)
+	}
+
+	// Feed dates are untrusted input; never let a parsing bug take down the caller
+	defer func() {
+		if r := recover(); r != nil {
+			t, err = time.Time{}, fmt.Errorf("unable to parse date: %s: %v", dateStr, r)
+		}
+	}()
 
 	// Try to parse EETE_R pattern
 	if strings.Contains(dateStr, "EETE_R") || strings.Contains(dateStr, "EESTE_R") {
@@ -236,7 +291,7 @@ func ParseDate(dateStr string) (time.Time, error) {
 
 	// Try all the standard layouts
 	for _, layout := range dateLayouts {
-		t, err = time.Parse(layout, dateStr)
+		t, err = parse(layout, dateStr)
 		if err == nil {
 			return t, nil
 		}
@@ -248,7 +303,13 @@ func ParseDate(dateStr string) (time.Time, error) {
 
 // ParseDateWithDefaultTZ sets timezone to UTC if not specified
 func ParseDateWithDefaultTZ(dateStr string) (time.Time, error) {
-	t, err := ParseDate(dateStr)
+	return ParseDateWithDefaultTZMaxLength(dateStr, DefaultMaxLength)
+}
+
+// ParseDateWithDefaultTZMaxLength is like ParseDateWithDefaultTZ but rejects
+// inputs longer than maxLength bytes.
+func ParseDateWithDefaultTZMaxLength(dateStr string, maxLength int) (time.Time, error) {
+	t, err := ParseDateWithMaxLength(dateStr, maxLength)
 	if err != nil {
 		return t, err
 	}
@@ -1,7 +1,10 @@
 package dateparser
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDateParsing(t *testing.T) {
@@ -179,3 +182,83 @@ func TestAllDateStrings(t *testing.T) {
 		t.Errorf("Too many parsing failures: %d (more than 5%% of total)", failCount)
 	}
 }
+
+func TestLayoutsReturnsCopy(t *testing.T) {
+	layouts := Layouts()
+	if len(layouts) != len(dateLayouts) {
+		t.Fatalf("Layouts() returned %d layouts, want %d", len(layouts), len(dateLayouts))
+	}
+
+	original := dateLayouts[0]
+	layouts[0] = "mutated"
+	if dateLayouts[0] != original {
+		t.Errorf("modifying the result of Layouts() changed the internal layout list")
+	}
+}
+
+func TestParseDateRejectsLongInput(t *testing.T) {
+	long := "Mon, 02 Jan 2006 15:04:05 -0700" + strings.Repeat(" ", 10*1024)
+	if _, err := ParseDate(long); !errors.Is(err, ErrDateTooLong) {
+		t.Errorf("ParseDate() error = %v for a %d byte input, want ErrDateTooLong", err, len(long))
+	}
+
+	date := "Mon, 02 Jan 2006 15:04:05 -0700"
+	if _, err := ParseDateWithMaxLength(date, len(date)-1); !errors.Is(err, ErrDateTooLong) {
+		t.Errorf("ParseDateWithMaxLength() error = %v, want ErrDateTooLong", err)
+	}
+	if _, err := ParseDateWithMaxLength(date, len(date)); err != nil {
+		t.Errorf("ParseDateWithMaxLength() failed at the exact limit: %v", err)
+	}
+}
+
+func TestParseDateWithLocation(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	testCases := []struct {
+		name    string
+		dateStr string
+		loc     *time.Location
+		want    time.Time
+	}{
+		{"zone-less in default location", "Tue, 18 Mar 2025 5:58:24 PM", madrid, time.Date(2025, 3, 18, 16, 58, 24, 0, time.UTC)},
+		{"zone-less with nil location", "Tue, 18 Mar 2025 5:58:24 PM", nil, time.Date(2025, 3, 18, 17, 58, 24, 0, time.UTC)},
+		{"explicit offset wins", "Mon, 17 Mar 2025 10:00:00 -0500", madrid, time.Date(2025, 3, 17, 15, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseDateWithLocation(tc.dateStr, tc.loc)
+			if err != nil {
+				t.Fatalf("ParseDateWithLocation(%q) error = %v", tc.dateStr, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("ParseDateWithLocation(%q) = %v, want %v", tc.dateStr, got.UTC(), tc.want)
+			}
+		})
+	}
+}
+
+func FuzzParseDate(f *testing.F) {
+	for _, seed := range []string{
+		"TueAMEETE_RMarchC822",
+		"Sun, 23 Mar 2025 08:14:46 Europe/Dublin",
+		"Sunday, March 23, 2025, 16:20 GMT +5:30",
+		"Domenica, 23 Marzo, 2025 - 10:33",
+		"22 مارس 2025",
+		"Mon, 17 Mar 2025 24:15:59 +0530",
+		"",
+		"\xff\xfe",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, dateStr string) {
+		result, err := ParseDateWithDefaultTZ(dateStr)
+		if err == nil && len(dateStr) > DefaultMaxLength {
+			t.Errorf("ParseDateWithDefaultTZ accepted a %d byte input: %v", len(dateStr), result)
+		}
+	})
+}
@@ -0,0 +1,151 @@
+// Package dnscache caches host name lookups for a bounded time.
+package dnscache
+
+import (
+	"container/list"
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// LookupFunc resolves host to its addresses and reports the smallest TTL of
+// the records in the answer.
+type LookupFunc func(ctx context.Context, host string) (addrs []netip.Addr, ttl time.Duration, err error)
+
+// Cache remembers the addresses of recently resolved hosts. An answer is kept
+// for the TTL its lookup reports, capped at the cache's maximum TTL; answers
+// with no TTL left and failed lookups are not cached. Only the most recently
+// used hosts are kept, up to a maximum number, and expired entries are swept
+// out as new ones are added.
+type Cache struct {
+	lookup    LookupFunc
+	ttl       time.Duration
+	max       int
+	mu        sync.Mutex
+	entries   map[string]*list.Element // of *entry, keyed by host
+	lru       *list.List               // most recently used first
+	nextSweep time.Time
+	now       func() time.Time
+}
+
+type entry struct {
+	host    string
+	addrs   []netip.Addr
+	expires time.Time
+}
+
+// New creates a Cache resolving hosts with resolver, or net.DefaultResolver
+// if it is nil, and keeping up to max results for ttl. Go's resolver does not
+// report record TTLs, so every answer is kept for ttl; keep it at or below
+// the TTLs of the records being cached, or use NewWithLookup.
+func New(resolver *net.Resolver, ttl time.Duration, max int) *Cache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return NewWithLookup(func(ctx context.Context, host string) ([]netip.Addr, time.Duration, error) {
+		addrs, err := resolver.LookupNetIP(ctx, "ip", host)
+		return addrs, ttl, err
+	}, ttl, max)
+}
+
+// NewWithLookup creates a Cache resolving hosts with lookup and keeping up to
+// max results for the TTL lookup reports, but no longer than ttl.
+func NewWithLookup(lookup LookupFunc, ttl time.Duration, max int) *Cache {
+	return &Cache{
+		lookup:  lookup,
+		ttl:     ttl,
+		max:     max,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+	}
+}
+
+// TTL returns the longest time the cache keeps an answer.
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Len returns the number of cached hosts, including expired ones not yet
+// swept out.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// LookupHost returns the addresses of host, from the cache if a lookup for it
+// has succeeded and its answer has not expired.
+func (c *Cache) LookupHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	if addrs, ok := c.get(host); ok {
+		return addrs, nil
+	}
+
+	// Concurrent misses for the same host may each resolve it; the cost
+	// is a few duplicate lookups, not a wrong answer.
+	addrs, ttl, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.put(host, addrs, min(ttl, c.ttl))
+
+	return addrs, nil
+}
+
+func (c *Cache) get(host string) ([]netip.Addr, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[host]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*entry)
+	if !c.now().Before(e.expires) {
+		c.remove(el)
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return e.addrs, true
+}
+
+func (c *Cache) put(host string, addrs []netip.Addr, ttl time.Duration) {
+	if ttl <= 0 || c.max <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if !now.Before(c.nextSweep) {
+		c.sweep(now)
+		c.nextSweep = now.Add(c.ttl)
+	}
+
+	if el, ok := c.entries[host]; ok {
+		c.remove(el)
+	}
+	c.entries[host] = c.lru.PushFront(&entry{host: host, addrs: addrs, expires: now.Add(ttl)})
+	for c.lru.Len() > c.max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// sweep removes the entries that have expired by now.
+func (c *Cache) sweep(now time.Time) {
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		if !now.Before(el.Value.(*entry).expires) {
+			c.remove(el)
+		}
+		el = next
+	}
+}
+
+func (c *Cache) remove(el *list.Element) {
+	delete(c.entries, el.Value.(*entry).host)
+	c.lru.Remove(el)
+}
@@ -0,0 +1,98 @@
+package dnscache
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Now()
+	lookups := 0
+	fail := false
+	c := NewWithLookup(func(ctx context.Context, host string) ([]netip.Addr, time.Duration, error) {
+		lookups++
+		if fail {
+			return nil, 0, errors.New("no such host")
+		}
+		return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, time.Hour, nil
+	}, time.Minute, 10)
+	c.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	addrs, err := c.LookupHost(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, "93.184.216.34", addrs[0].String())
+
+	_, err = c.LookupHost(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lookups, "second lookup served from cache")
+
+	now = now.Add(time.Minute)
+	_, err = c.LookupHost(ctx, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups, "expired entries are resolved again")
+
+	fail = true
+	_, err = c.LookupHost(ctx, "other.com")
+	assert.Error(t, err)
+	_, err = c.LookupHost(ctx, "other.com")
+	assert.Error(t, err)
+	assert.Equal(t, 4, lookups, "failures are not cached")
+}
+
+func TestCache_RecordTTL(t *testing.T) {
+	now := time.Now()
+	lookups := 0
+	ttls := map[string]time.Duration{"short.com": time.Second, "none.com": 0}
+	c := NewWithLookup(func(ctx context.Context, host string) ([]netip.Addr, time.Duration, error) {
+		lookups++
+		return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, ttls[host], nil
+	}, time.Minute, 10)
+	c.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_, err := c.LookupHost(ctx, "short.com")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Second)
+	_, err = c.LookupHost(ctx, "short.com")
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups, "answers expire with their record TTL")
+
+	_, err = c.LookupHost(ctx, "none.com")
+	require.NoError(t, err)
+	_, err = c.LookupHost(ctx, "none.com")
+	require.NoError(t, err)
+	assert.Equal(t, 4, lookups, "answers with a zero TTL are not cached")
+}
+
+func TestCache_Bounded(t *testing.T) {
+	now := time.Now()
+	lookups := map[string]int{}
+	c := NewWithLookup(func(ctx context.Context, host string) ([]netip.Addr, time.Duration, error) {
+		lookups[host]++
+		return []netip.Addr{netip.MustParseAddr("93.184.216.34")}, time.Minute, nil
+	}, time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for _, host := range []string{"a.com", "b.com", "a.com", "c.com", "a.com", "b.com"} {
+		_, err := c.LookupHost(ctx, host)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 1, lookups["a.com"], "recently used hosts are kept")
+	assert.Equal(t, 2, lookups["b.com"], "least recently used hosts are evicted")
+
+	t.Run("expired entries are swept", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		_, err := c.LookupHost(ctx, "d.com")
+		require.NoError(t, err)
+		assert.Equal(t, 1, c.Len())
+	})
+}
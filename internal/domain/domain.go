@@ -0,0 +1,44 @@
+// Package domain provides helpers for grouping hosts by registrable domain.
+package domain
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Registrable returns the registrable domain (eTLD+1) of host, e.g.
+// "example.co.uk" for "news.example.co.uk". Ports are ignored. Hosts without
+// a registrable domain, such as IP addresses or "localhost", are returned
+// lowercased as they are.
+func Registrable(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(hostname(host), "."))
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return registrable
+}
+
+// FromURL returns the registrable domain of rawURL's host, or an empty string
+// if rawURL cannot be parsed.
+func FromURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return Registrable(u.Host)
+}
+
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return strings.Trim(host, "[]")
+}
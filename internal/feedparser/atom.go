@@ -0,0 +1,113 @@
+package feedparser
+
+import (
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/atom"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// AtomNamespace is the extension namespace under which atom elements dropped
+// by gofeed's universal model are preserved. gofeed already uses it for atom
+// elements embedded in RSS feeds, so both formats can be read the same way.
+const AtomNamespace = "atom"
+
+// atomTranslator extends gofeed's default Atom translation by preserving
+// elements the universal feed model drops.
+type atomTranslator struct {
+	gofeed.DefaultAtomTranslator
+}
+
+func (t *atomTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	result, err := t.DefaultAtomTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	af, ok := feed.(*atom.Feed)
+	if !ok {
+		return result, nil
+	}
+
+	if af.Icon != "" {
+		result.Extensions = addAtomExtension(result.Extensions, ext.Extension{Name: "icon", Value: af.Icon})
+	}
+	for _, link := range af.Links {
+		if link != nil && link.Href != "" {
+			result.Extensions = addAtomExtension(result.Extensions, linkExtension(link))
+		}
+	}
+
+	// Items are translated one-to-one from entries, in order
+	for i, entry := range af.Entries {
+		if i >= len(result.Items) || result.Items[i] == nil {
+			break
+		}
+		translateEntryLinks(entry, result.Items[i])
+		translateEntrySource(entry, result.Items[i])
+	}
+
+	return result, nil
+}
+
+// translateEntryLinks records every link of an entry with its relation and
+// type, and fills in the item link from a link without rel, which the Atom
+// spec defines as an alternate link but gofeed ignores.
+func translateEntryLinks(entry *atom.Entry, item *gofeed.Item) {
+	for _, link := range entry.Links {
+		if link == nil || link.Href == "" {
+			continue
+		}
+
+		rel := link.Rel
+		if rel == "" {
+			rel = "alternate"
+		}
+		if rel == "alternate" && item.Link == "" {
+			item.Link = link.Href
+		}
+
+		e := linkExtension(link)
+		e.Attrs["rel"] = rel
+		item.Extensions = addAtomExtension(item.Extensions, e)
+	}
+}
+
+// linkExtension records an atom link the way gofeed records atom:link
+// elements embedded in RSS.
+func linkExtension(link *atom.Link) ext.Extension {
+	return ext.Extension{
+		Name: "link",
+		Attrs: map[string]string{
+			"href": link.Href,
+			"rel":  link.Rel,
+			"type": link.Type,
+		},
+	}
+}
+
+func addAtomExtension(extensions ext.Extensions, e ext.Extension) ext.Extensions {
+	return addExtension(extensions, AtomNamespace, e)
+}
+
+func addExtension(extensions ext.Extensions, namespace string, e ext.Extension) ext.Extensions {
+	if extensions == nil {
+		extensions = ext.Extensions{}
+	}
+	if extensions[namespace] == nil {
+		extensions[namespace] = map[string][]ext.Extension{}
+	}
+	extensions[namespace][e.Name] = append(extensions[namespace][e.Name], e)
+	return extensions
+}
+
+// AtomExtensions returns the atom elements named name recorded in extensions.
+func AtomExtensions(extensions ext.Extensions, name string) []ext.Extension {
+	return extensions[AtomNamespace][name]
+}
+
+func newGoFeedParser() *gofeed.Parser {
+	parser := gofeed.NewParser()
+	parser.AtomTranslator = &atomTranslator{}
+	parser.RSSTranslator = &rssTranslator{}
+	return parser
+}
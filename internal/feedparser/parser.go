@@ -1,25 +1,174 @@
 package feedparser
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/mmcdole/gofeed"
 )
 
+// ErrBodyTooLarge is returned by Fetch for a response body larger than
+// Request.MaxBodySize.
+var ErrBodyTooLarge = errors.New("response body exceeds maximum size")
+
 type Parser interface {
 	ParseURLWithContext(url string, ctx context.Context) (*gofeed.Feed, error)
+	Parse(r io.Reader) (*gofeed.Feed, error)
+}
+
+// Request describes a single feed request. Zero-valued fields fall back to
+// the parser defaults.
+type Request struct {
+	URL         string
+	UserAgent   string
+	Header      http.Header
+	MaxBodySize int64 // Larger bodies fail with ErrBodyTooLarge; 0 = no limit
+}
+
+// StatusError is returned for non-2xx responses. It carries the response
+// headers, e.g. Retry-After, and unwraps to gofeed.HTTPError.
+type StatusError struct {
+	gofeed.HTTPError
+	Header http.Header
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.HTTPError
 }
 
+// BytesParser is implemented by parsers that can parse a document already
+// in memory without copying it first.
+type BytesParser interface {
+	ParseBytes(doc []byte) (*gofeed.Feed, error)
+}
+
+// Fetcher is implemented by parsers that can download a feed without parsing
+// it, so the raw response can be inspected, stored or size-checked before
+// being handed to Parse.
+type Fetcher interface {
+	Fetch(ctx context.Context, req Request) (body []byte, header http.Header, err error)
+}
+
+// GoFeedParser downloads feeds over HTTP and parses them with gofeed. It is
+// safe for concurrent use.
 type GoFeedParser struct {
-	parser *gofeed.Parser
+	client    *http.Client
+	userAgent string
 }
 
 func NewGoFeedParser(userAgent string) *GoFeedParser {
-	parser := gofeed.NewParser()
-	parser.UserAgent = userAgent
-	return &GoFeedParser{parser: parser}
+	return NewGoFeedParserWithClient(userAgent, &http.Client{})
+}
+
+// NewGoFeedParserWithClient creates a GoFeedParser that downloads feeds with
+// the given HTTP client.
+func NewGoFeedParserWithClient(userAgent string, client *http.Client) *GoFeedParser {
+	return &GoFeedParser{
+		client:    client,
+		userAgent: userAgent,
+	}
+}
+
+// Parse parses an already downloaded feed document. The xml:base
+// declarations in scope for RSS items are recorded for ItemBase.
+func (p *GoFeedParser) Parse(r io.Reader) (*gofeed.Feed, error) {
+	doc, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseBytes(doc)
+}
+
+// ParseBytes is Parse for a document already read into memory, which it does
+// not modify.
+func (p *GoFeedParser) ParseBytes(doc []byte) (*gofeed.Feed, error) {
+	// gofeed parsers keep per-document state, so they cannot be shared
+	feed, err := newGoFeedParser().Parse(bytes.NewReader(doc))
+	if err != nil {
+		return nil, err
+	}
+	recordXMLBase(doc, feed)
+	return feed, nil
 }
 
+// ParseURLWithContext downloads and parses a feed in one call. It is a
+// convenience wrapper around Fetch and Parse.
 func (p *GoFeedParser) ParseURLWithContext(url string, ctx context.Context) (*gofeed.Feed, error) {
-	return p.parser.ParseURLWithContext(url, ctx)
+	body, _, err := p.Fetch(ctx, Request{URL: url})
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseBytes(body)
+}
+
+// Fetch downloads the feed described by req and returns the raw body and
+// response headers. Non-2xx responses are reported as a *StatusError. With
+// req.MaxBodySize set, no more than that is read: a larger body, whether
+// announced by Content-Length or not, fails with ErrBodyTooLarge.
+func (p *GoFeedParser) Fetch(ctx context.Context, req Request) ([]byte, http.Header, error) {
+	resp, err := p.do(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var r io.Reader = resp.Body
+	if req.MaxBodySize > 0 {
+		if resp.ContentLength > req.MaxBodySize {
+			return nil, nil, fmt.Errorf("%w: %d bytes, limit %d", ErrBodyTooLarge, resp.ContentLength, req.MaxBodySize)
+		}
+		// One byte more than allowed tells a body at the limit from a larger one
+		r = io.LimitReader(resp.Body, req.MaxBodySize+1)
+	}
+
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.MaxBodySize > 0 && int64(len(body)) > req.MaxBodySize {
+		return nil, nil, fmt.Errorf("%w: more than %d bytes", ErrBodyTooLarge, req.MaxBodySize)
+	}
+	return body, resp.Header, nil
+}
+
+// do sends the request and checks the response status.
+func (p *GoFeedParser) do(ctx context.Context, req Request) (*http.Response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, req.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range req.Header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+
+	userAgent := p.userAgent
+	if req.UserAgent != "" {
+		userAgent = req.UserAgent
+	}
+	httpReq.Header.Set("User-Agent", userAgent)
+
+	resp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		resp.Body.Close()
+		return nil, &StatusError{
+			HTTPError: gofeed.HTTPError{
+				StatusCode: resp.StatusCode,
+				Status:     resp.Status,
+			},
+			Header: resp.Header,
+		}
+	}
+
+	return resp, nil
 }
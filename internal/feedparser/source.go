@@ -0,0 +1,85 @@
+package feedparser
+
+import (
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/mmcdole/gofeed/atom"
+	ext "github.com/mmcdole/gofeed/extensions"
+	"github.com/mmcdole/gofeed/rss"
+)
+
+// RSSNamespace is the extension namespace under which RSS elements dropped
+// by gofeed's universal model are preserved.
+const RSSNamespace = "rss"
+
+// rssTranslator extends gofeed's default RSS translation by preserving
+// elements the universal feed model drops.
+type rssTranslator struct {
+	gofeed.DefaultRSSTranslator
+}
+
+func (t *rssTranslator) Translate(feed interface{}) (*gofeed.Feed, error) {
+	result, err := t.DefaultRSSTranslator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	rf, ok := feed.(*rss.Feed)
+	if !ok {
+		return result, nil
+	}
+
+	// Items are translated one-to-one, in order
+	for i, item := range rf.Items {
+		if i >= len(result.Items) || result.Items[i] == nil {
+			break
+		}
+		if item.Source != nil {
+			result.Items[i].Extensions = addExtension(result.Items[i].Extensions, RSSNamespace, ext.Extension{
+				Name:  "source",
+				Value: item.Source.Title,
+				Attrs: map[string]string{"url": item.Source.URL},
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// translateEntrySource records the title and alternate link of an entry's
+// atom:source, the feed it was copied from.
+func translateEntrySource(entry *atom.Entry, item *gofeed.Item) {
+	if entry.Source == nil {
+		return
+	}
+	var href string
+	for _, link := range entry.Source.Links {
+		if link != nil && (link.Rel == "" || link.Rel == "alternate") {
+			href = link.Href
+			break
+		}
+	}
+	item.Extensions = addAtomExtension(item.Extensions, ext.Extension{
+		Name:  "source",
+		Value: entry.Source.Title,
+		Attrs: map[string]string{"href": href},
+	})
+}
+
+// ItemSource returns the name and url of the feed an item was republished
+// from, as declared by an RSS <source> or Atom <source> element. Both are
+// empty when the item declares no source; the url is returned unresolved.
+func ItemSource(item *gofeed.Item) (name, url string) {
+	if sources := item.Extensions[RSSNamespace]["source"]; len(sources) > 0 {
+		return strings.TrimSpace(sources[0].Value), strings.TrimSpace(sources[0].Attrs["url"])
+	}
+	for _, source := range AtomExtensions(item.Extensions, "source") {
+		// atom:source elements inside RSS are kept by gofeed with children
+		// instead; only those recorded by the atom translator have attrs
+		if href, ok := source.Attrs["href"]; ok {
+			return strings.TrimSpace(source.Value), strings.TrimSpace(href)
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,106 @@
+package feedparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// XMLNamespace is the extension namespace under which the xml:base
+// declarations in scope for an item are recorded, outermost first.
+const XMLNamespace = "xml"
+
+// xmlURL is the namespace encoding/xml reports for the reserved xml prefix.
+const xmlURL = "http://www.w3.org/XML/1998/namespace"
+
+// recordXMLBase records on each item of an RSS feed the xml:base
+// declarations of the item and its ancestors in doc. gofeed applies xml:base
+// to Atom documents itself but ignores it in RSS. Nothing is recorded when
+// doc cannot be scanned or its items do not line up with feed's.
+func recordXMLBase(doc []byte, feed *gofeed.Feed) {
+	if feed.FeedType != "rss" || !bytes.Contains(doc, []byte("xml:base")) {
+		return
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	d.Strict = false
+	// Only attribute values are read, and base URLs are ASCII in practice
+	d.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+
+	var (
+		chain  []string // xml:base values in scope
+		pushed []bool   // whether each open element added to chain
+		bases  [][]string
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			base, ok := xmlBaseAttr(t.Attr)
+			if ok {
+				chain = append(chain, base)
+			}
+			pushed = append(pushed, ok)
+			if t.Name.Local == "item" {
+				bases = append(bases, slices.Clone(chain))
+			}
+		case xml.EndElement:
+			if len(pushed) == 0 {
+				return
+			}
+			if pushed[len(pushed)-1] {
+				chain = chain[:len(chain)-1]
+			}
+			pushed = pushed[:len(pushed)-1]
+		}
+	}
+
+	if len(bases) != len(feed.Items) {
+		return
+	}
+	for i, item := range feed.Items {
+		if item == nil {
+			continue
+		}
+		for _, base := range bases[i] {
+			item.Extensions = addExtension(item.Extensions, XMLNamespace, ext.Extension{Name: "base", Value: base})
+		}
+	}
+}
+
+func xmlBaseAttr(attrs []xml.Attr) (string, bool) {
+	for _, attr := range attrs {
+		if attr.Name.Local == "base" && (attr.Name.Space == xmlURL || attr.Name.Space == "xml") {
+			return strings.TrimSpace(attr.Value), true
+		}
+	}
+	return "", false
+}
+
+// ItemBase returns the URL relative links of item resolve against: feedURL
+// with the item's recorded xml:base declarations applied in turn. It returns
+// feedURL when there are none, and stops at the first that does not parse.
+func ItemBase(feedURL *url.URL, item *gofeed.Item) *url.URL {
+	base := feedURL
+	for _, e := range item.Extensions[XMLNamespace]["base"] {
+		ref, err := url.Parse(e.Value)
+		if err != nil {
+			break
+		}
+		base = base.ResolveReference(ref)
+	}
+	return base
+}
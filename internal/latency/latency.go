@@ -0,0 +1,85 @@
+// Package latency tracks request latency per key.
+package latency
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// alpha weights the newest sample in the moving average.
+	alpha = 0.2
+	// window is the number of recent samples the percentile is taken over.
+	window = 100
+)
+
+// Stats summarizes the latency recorded for a key.
+type Stats struct {
+	EMA     time.Duration
+	P95     time.Duration
+	Samples int
+}
+
+// Tracker records latencies per key. Each key has its own lock, so
+// concurrent requests to different keys do not contend with each other.
+type Tracker struct {
+	series sync.Map // string -> *series
+}
+
+type series struct {
+	mu      sync.Mutex
+	ema     float64
+	recent  [window]time.Duration
+	next    int
+	samples int
+}
+
+// New creates an empty Tracker.
+func New() *Tracker {
+	return &Tracker{}
+}
+
+// Record adds a latency sample for key.
+func (t *Tracker) Record(key string, d time.Duration) {
+	v, ok := t.series.Load(key)
+	if !ok {
+		v, _ = t.series.LoadOrStore(key, &series{})
+	}
+	s := v.(*series)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.samples == 0 {
+		s.ema = float64(d)
+	} else {
+		s.ema = alpha*float64(d) + (1-alpha)*s.ema
+	}
+	s.recent[s.next] = d
+	s.next = (s.next + 1) % window
+	s.samples++
+}
+
+// Stats returns the latency summary for key and whether any samples have
+// been recorded for it. P95 covers the most recent samples only.
+func (t *Tracker) Stats(key string) (Stats, bool) {
+	v, ok := t.series.Load(key)
+	if !ok {
+		return Stats{}, false
+	}
+	s := v.(*series)
+
+	s.mu.Lock()
+	n := min(s.samples, window)
+	recent := make([]time.Duration, n)
+	copy(recent, s.recent[:n])
+	stats := Stats{EMA: time.Duration(s.ema), Samples: s.samples}
+	s.mu.Unlock()
+
+	sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+	// Nearest-rank percentile
+	rank := (95*n + 99) / 100
+	stats.P95 = recent[rank-1]
+	return stats, true
+}
@@ -0,0 +1,43 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	tr := New()
+
+	_, ok := tr.Stats("example.com")
+	assert.False(t, ok, "no samples yet")
+
+	for i := 1; i <= 100; i++ {
+		tr.Record("example.com", time.Duration(i)*time.Millisecond)
+	}
+
+	stats, ok := tr.Stats("example.com")
+	assert.True(t, ok)
+	assert.Equal(t, 100, stats.Samples)
+	assert.Equal(t, 95*time.Millisecond, stats.P95)
+	assert.Greater(t, stats.EMA, 50*time.Millisecond, "the average favors recent samples")
+	assert.Less(t, stats.EMA, 100*time.Millisecond)
+
+	_, ok = tr.Stats("other.com")
+	assert.False(t, ok, "keys are tracked separately")
+}
+
+func TestTracker_WindowSlides(t *testing.T) {
+	tr := New()
+	for i := 0; i < window; i++ {
+		tr.Record("example.com", time.Second)
+	}
+	for i := 0; i < window; i++ {
+		tr.Record("example.com", time.Millisecond)
+	}
+
+	stats, _ := tr.Stats("example.com")
+	assert.Equal(t, time.Millisecond, stats.P95, "old samples leave the window")
+	assert.Equal(t, 2*window, stats.Samples)
+}
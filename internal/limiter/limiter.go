@@ -2,10 +2,14 @@ package limiter
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -18,25 +22,108 @@ type RateLimiter interface {
 
 // DomainRateLimiter limits requests by domain
 type DomainRateLimiter struct {
-	limiters map[string]*rate.Limiter
+	limiters map[string]*domainLimiter
 	mu       sync.RWMutex
 	r        rate.Limit
 	b        int
+
+	// Per-domain overrides of r and b, see SetLimit
+	exact    map[string]Limit
+	suffixes map[string]Limit
+}
+
+// domainLimiter is the limiter of a single domain.
+type domainLimiter struct {
+	*rate.Limiter
+	lastUsed atomic.Int64 // UnixNano of the last WaitForDomain; 0 if never used
+}
+
+func newDomainLimiter(limit Limit) *domainLimiter {
+	return &domainLimiter{Limiter: rate.NewLimiter(limit.Rate, limit.Burst)}
+}
+
+// DomainStat is a snapshot of one domain's limiter.
+type DomainStat struct {
+	Tokens   float64   // Requests that could be made right now without waiting
+	LastUsed time.Time // Zero if no request has been made yet
+}
+
+// Limit is the rate and burst applied to a domain.
+type Limit struct {
+	Rate  rate.Limit
+	Burst int
 }
 
 // NewDomainRateLimiter creates a rate limiter that limits by domain
 // r is requests per second, b is burst size
 func NewDomainRateLimiter(r rate.Limit, b int) *DomainRateLimiter {
 	return &DomainRateLimiter{
-		limiters: make(map[string]*rate.Limiter),
+		limiters: make(map[string]*domainLimiter),
 		r:        r,
 		b:        b,
 		mu:       sync.RWMutex{},
+		exact:    make(map[string]Limit),
+		suffixes: make(map[string]Limit),
+	}
+}
+
+// SetLimit overrides the rate and burst for domains matching pattern. A
+// pattern is either a host such as "example.com", which matches only that
+// host, or a wildcard such as "*.wordpress.com", which matches every
+// subdomain of wordpress.com but not wordpress.com itself. A leading "www."
+// is ignored, as in WaitForDomain.
+//
+// When several rules match, an exact host rule wins over any wildcard, and
+// among wildcards the longest suffix wins: "*.blog.example.com" beats
+// "*.example.com" for "a.blog.example.com". Hosts matching no rule use the
+// limiter's default rate and burst. Setting a rule also updates limiters
+// already created for matching hosts.
+func (l *DomainRateLimiter) SetLimit(pattern string, r rate.Limit, b int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := Limit{Rate: r, Burst: b}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		l.suffixes[suffix] = limit
+	} else {
+		l.exact[strings.TrimPrefix(pattern, "www.")] = limit
+	}
+
+	for host, limiter := range l.limiters {
+		limit := l.limitFor(host)
+		limiter.SetLimit(limit.Rate)
+		limiter.SetBurst(limit.Burst)
+	}
+}
+
+// limitFor returns the most specific limit for host. The caller must hold mu.
+func (l *DomainRateLimiter) limitFor(host string) Limit {
+	// Rules are written without ports
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	if limit, ok := l.exact[host]; ok {
+		return limit
+	}
+
+	// Walk up the labels so the longest matching suffix is found first
+	for {
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			break
+		}
+		host = host[i+1:]
+		if limit, ok := l.suffixes[host]; ok {
+			return limit
+		}
 	}
+
+	return Limit{Rate: l.r, Burst: l.b}
 }
 
 // getLimiter gets or creates a limiter for a domain
-func (l *DomainRateLimiter) getLimiter(domain string) *rate.Limiter {
+func (l *DomainRateLimiter) getLimiter(domain string) *domainLimiter {
 	l.mu.RLock()
 	limiter, exists := l.limiters[domain]
 	l.mu.RUnlock()
@@ -45,7 +132,7 @@ func (l *DomainRateLimiter) getLimiter(domain string) *rate.Limiter {
 		l.mu.Lock()
 		// Double-check to avoid race conditions
 		if limiter, exists = l.limiters[domain]; !exists {
-			limiter = rate.NewLimiter(l.r, l.b)
+			limiter = newDomainLimiter(l.limitFor(domain))
 			l.limiters[domain] = limiter
 		}
 		l.mu.Unlock()
@@ -54,8 +141,38 @@ func (l *DomainRateLimiter) getLimiter(domain string) *rate.Limiter {
 	return limiter
 }
 
+// Prime creates limiters for domains up front so the first request to each
+// does not have to take the write lock. Domains are host names and are
+// normalized the same way as in WaitForDomain; existing limiters are kept.
+func (l *DomainRateLimiter) Prime(domains []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, domain := range domains {
+		host := strings.TrimPrefix(domain, "www.")
+		if host == "" {
+			continue
+		}
+		if _, exists := l.limiters[host]; !exists {
+			l.limiters[host] = newDomainLimiter(l.limitFor(host))
+		}
+	}
+}
+
+// ErrRateLimited is returned by WaitForDomainWithin when the wait would
+// exceed its limit.
+var ErrRateLimited = errors.New("rate limit wait exceeds the allowed maximum")
+
 // WaitForDomain waits until a request is allowed for the domain
 func (l *DomainRateLimiter) WaitForDomain(ctx context.Context, urlStr string) error {
+	return l.WaitForDomainWithin(ctx, urlStr, 0)
+}
+
+// WaitForDomainWithin is like WaitForDomain but fails immediately with
+// ErrRateLimited, without using up a token, if the request would have to
+// wait longer than maxWait. A maxWait of zero or less waits as long as ctx
+// allows.
+func (l *DomainRateLimiter) WaitForDomainWithin(ctx context.Context, urlStr string, maxWait time.Duration) error {
 	u, err := url.Parse(urlStr)
 	if err != nil {
 		return fmt.Errorf("failed to parse URL for rate limiting: %w", err)
@@ -67,5 +184,49 @@ func (l *DomainRateLimiter) WaitForDomain(ctx context.Context, urlStr string) er
 		return fmt.Errorf("empty host in URL: %s", urlStr)
 	}
 
-	return l.getLimiter(host).Wait(ctx)
+	limiter := l.getLimiter(host)
+	if maxWait <= 0 {
+		limiter.lastUsed.Store(time.Now().UnixNano())
+		return limiter.Wait(ctx)
+	}
+
+	reservation := limiter.Reserve()
+	delay := reservation.Delay()
+	if !reservation.OK() || delay > maxWait {
+		reservation.Cancel()
+		return fmt.Errorf("%w: %s would wait %v", ErrRateLimited, host, delay)
+	}
+	limiter.lastUsed.Store(time.Now().UnixNano())
+
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Stats returns a snapshot of every tracked domain's limiter, keyed by the
+// normalized host. All token counts are taken at the same instant under the
+// lock, so they can be compared with each other.
+func (l *DomainRateLimiter) Stats() map[string]DomainStat {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	now := time.Now()
+	stats := make(map[string]DomainStat, len(l.limiters))
+	for domain, limiter := range l.limiters {
+		stat := DomainStat{Tokens: limiter.TokensAt(now)}
+		if ns := limiter.lastUsed.Load(); ns != 0 {
+			stat.LastUsed = time.Unix(0, ns)
+		}
+		stats[domain] = stat
+	}
+	return stats
 }
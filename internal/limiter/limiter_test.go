@@ -0,0 +1,92 @@
+package limiter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestDomainRateLimiter_Prime(t *testing.T) {
+	l := NewDomainRateLimiter(rate.Limit(1), 3)
+	existing := l.getLimiter("example.com")
+
+	l.Prime([]string{"www.example.com", "other.com", ""})
+
+	assert.Len(t, l.limiters, 2)
+	assert.Same(t, existing, l.limiters["example.com"], "existing limiters are kept")
+	assert.Contains(t, l.limiters, "other.com")
+}
+
+func TestDomainRateLimiter_SetLimit(t *testing.T) {
+	l := NewDomainRateLimiter(rate.Limit(1), 3)
+	l.SetLimit("*.example.com", rate.Limit(0.5), 1)
+	l.SetLimit("*.blog.example.com", rate.Limit(0.25), 1)
+	l.SetLimit("news.blog.example.com", rate.Limit(2), 5)
+
+	tests := []struct {
+		host string
+		want Limit
+	}{
+		{"example.com", Limit{1, 3}},
+		{"a.example.com", Limit{0.5, 1}},
+		{"a.example.com:8080", Limit{0.5, 1}},
+		{"blog.example.com", Limit{0.5, 1}},
+		{"a.blog.example.com", Limit{0.25, 1}},
+		{"news.blog.example.com", Limit{2, 5}},
+		{"other.com", Limit{1, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			limiter := l.getLimiter(tt.host)
+			assert.Equal(t, tt.want.Rate, limiter.Limit())
+			assert.Equal(t, tt.want.Burst, limiter.Burst())
+		})
+	}
+}
+
+func TestDomainRateLimiter_SetLimitUpdatesExisting(t *testing.T) {
+	l := NewDomainRateLimiter(rate.Limit(1), 3)
+	limiter := l.getLimiter("a.example.com")
+
+	l.SetLimit("*.example.com", rate.Limit(0.5), 1)
+
+	assert.Equal(t, rate.Limit(0.5), limiter.Limit())
+	assert.Equal(t, 1, limiter.Burst())
+}
+
+func TestDomainRateLimiter_Stats(t *testing.T) {
+	l := NewDomainRateLimiter(rate.Limit(1), 3)
+	l.Prime([]string{"idle.com"})
+
+	before := time.Now()
+	require.NoError(t, l.WaitForDomain(context.Background(), "https://www.example.com/feed"))
+
+	stats := l.Stats()
+	require.Len(t, stats, 2)
+
+	assert.InDelta(t, 2, stats["example.com"].Tokens, 0.1, "one of three tokens used")
+	assert.False(t, stats["example.com"].LastUsed.Before(before))
+
+	assert.InDelta(t, 3, stats["idle.com"].Tokens, 0.001)
+	assert.True(t, stats["idle.com"].LastUsed.IsZero(), "primed but never used")
+}
+
+func TestDomainRateLimiter_WaitForDomainWithin(t *testing.T) {
+	l := NewDomainRateLimiter(rate.Every(time.Hour), 1)
+	ctx := context.Background()
+
+	require.NoError(t, l.WaitForDomainWithin(ctx, "https://example.com/a", 10*time.Millisecond), "burst token")
+
+	start := time.Now()
+	err := l.WaitForDomainWithin(ctx, "https://example.com/b", 10*time.Millisecond)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Less(t, time.Since(start), time.Second, "fails fast instead of waiting")
+
+	l.SetLimit("example.com", rate.Every(20*time.Millisecond), 1)
+	assert.NoError(t, l.WaitForDomainWithin(ctx, "https://example.com/c", time.Second), "short waits are still served")
+}
@@ -0,0 +1,88 @@
+// Package novelty tracks, per feed, how many fetches in a row brought no new
+// items.
+package novelty
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Tracker remembers the item keys of each feed's last fetch and counts
+// consecutive fetches without a key it had not seen in the fetch before. It
+// keeps at most maxFeeds feeds, forgetting the least recently observed one
+// when full, so memory is bounded by maxFeeds times the items per fetch.
+type Tracker struct {
+	maxFeeds int
+	mu       sync.Mutex
+	feeds    map[string]*list.Element // of *feedState
+	lru      *list.List               // most recently observed first
+}
+
+type feedState struct {
+	feed  string
+	keys  map[uint64]struct{}
+	empty int
+}
+
+// New creates a Tracker holding up to maxFeeds feeds; maxFeeds must be
+// positive.
+func New(maxFeeds int) *Tracker {
+	return &Tracker{
+		maxFeeds: maxFeeds,
+		feeds:    make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Observe records the item keys of a successful fetch of feed and returns
+// the number of keys not present in the previous fetch along with the
+// updated count of consecutive fetches without new keys. The first fetch of
+// a feed establishes its keys and counts neither way.
+func (t *Tracker) Observe(feed string, keys []uint64) (newKeys, consecutiveEmpty int) {
+	current := make(map[uint64]struct{}, len(keys))
+	for _, k := range keys {
+		current[k] = struct{}{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.feeds[feed]
+	if !ok {
+		t.feeds[feed] = t.lru.PushFront(&feedState{feed: feed, keys: current})
+		if t.lru.Len() > t.maxFeeds {
+			oldest := t.lru.Back()
+			t.lru.Remove(oldest)
+			delete(t.feeds, oldest.Value.(*feedState).feed)
+		}
+		return len(current), 0
+	}
+
+	t.lru.MoveToFront(elem)
+	state := elem.Value.(*feedState)
+	for k := range current {
+		if _, seen := state.keys[k]; !seen {
+			newKeys++
+		}
+	}
+	if newKeys == 0 {
+		state.empty++
+	} else {
+		state.empty = 0
+	}
+	state.keys = current
+	return newKeys, state.empty
+}
+
+// ConsecutiveEmpty returns the number of fetches in a row without new keys
+// for feed, and whether the feed is being tracked.
+func (t *Tracker) ConsecutiveEmpty(feed string) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	elem, ok := t.feeds[feed]
+	if !ok {
+		return 0, false
+	}
+	return elem.Value.(*feedState).empty, true
+}
@@ -0,0 +1,54 @@
+package novelty
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracker(t *testing.T) {
+	tr := New(10)
+
+	_, ok := tr.ConsecutiveEmpty("a")
+	assert.False(t, ok, "untracked")
+
+	newKeys, empty := tr.Observe("a", []uint64{1, 2})
+	assert.Equal(t, 2, newKeys)
+	assert.Equal(t, 0, empty, "first fetch is not empty")
+
+	newKeys, empty = tr.Observe("a", []uint64{2, 1})
+	assert.Equal(t, 0, newKeys)
+	assert.Equal(t, 1, empty)
+
+	_, empty = tr.Observe("a", nil)
+	assert.Equal(t, 2, empty, "a fetch without items is empty")
+
+	newKeys, empty = tr.Observe("a", []uint64{1})
+	assert.Equal(t, 1, newKeys, "compared with the previous fetch only")
+	assert.Equal(t, 0, empty)
+
+	_, empty = tr.Observe("a", []uint64{1})
+	assert.Equal(t, 1, empty)
+	got, ok := tr.ConsecutiveEmpty("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, got)
+
+	_, empty = tr.Observe("b", []uint64{1})
+	assert.Equal(t, 0, empty, "feeds are tracked separately")
+}
+
+func TestTracker_Bounded(t *testing.T) {
+	tr := New(2)
+	tr.Observe("a", []uint64{1})
+	tr.Observe("b", []uint64{1})
+	tr.Observe("a", []uint64{1}) // a is now the most recent
+	tr.Observe("c", []uint64{1})
+
+	_, ok := tr.ConsecutiveEmpty("b")
+	assert.False(t, ok, "least recently observed feed is forgotten")
+	got, ok := tr.ConsecutiveEmpty("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, got)
+	_, ok = tr.ConsecutiveEmpty("c")
+	assert.True(t, ok)
+}
@@ -3,13 +3,17 @@ package validation
 import (
 	"errors"
 	"fmt"
+	"html"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/mmcdole/gofeed"
 	"github.com/reddot-watch/feedfetcher/internal/dateparser"
+	"github.com/reddot-watch/feedfetcher/internal/domain"
 )
 
 var (
@@ -20,25 +24,127 @@ var (
 	ErrPublicationTooOld         = errors.New("publication date exceeds maximum age")
 	ErrFuturePublication         = errors.New("publication date is in the future beyond allowed tolerance")
 	ErrMissingPublishDate        = errors.New("missing publication date")
+	ErrCrossDomainLink           = errors.New("link points to a different domain than the feed")
+	ErrURLTooLong                = errors.New("url exceeds maximum length")
+
+	// ErrAfterMaxDate is the ErrFuturePublication of a date after
+	// DateOptions.MaxDate rather than beyond the future tolerance.
+	ErrAfterMaxDate = fmt.Errorf("%w: after the maximum publication date", ErrFuturePublication)
 )
 
 // ValidateAndResolveURL validates and resolves a relative url against the feed url.
 // Extracted as a package function for better testability.
 func ValidateAndResolveURL(feedURL *url.URL, rawURL string) (string, error) {
+	return ValidateAndResolveURLWithOptions(feedURL, rawURL, URLOptions{})
+}
+
+// URLOptions controls how ValidateAndResolveURLWithOptions treats links.
+type URLOptions struct {
+	// RejectCrossDomain rejects links whose resolved host differs from the
+	// feed's with ErrCrossDomainLink. With SameSite, any host under the
+	// feed's registrable domain is accepted instead, e.g. www.example.com
+	// for a feed on news.example.com.
+	RejectCrossDomain bool
+	SameSite          bool
+
+	// MaxLength rejects links whose resolved form is longer than this many
+	// bytes with ErrURLTooLong; zero or less disables the check.
+	MaxLength int
+
+	// UnescapeEntities decodes HTML character references such as &amp; in
+	// links before parsing them, for feeds that escape their links twice.
+	// Only references ending in a semicolon are decoded, so parameters such
+	// as &region= or &copy= survive, but it would still corrupt a link that
+	// legitimately contains one.
+	UnescapeEntities bool
+
+	// Base, when set, is the URL links are resolved against instead of the
+	// feed url, e.g. one declared by xml:base. RejectCrossDomain still
+	// compares with the feed url.
+	Base *url.URL
+}
+
+// ValidateAndResolveURLWithOptions is like ValidateAndResolveURL but takes
+// its settings from opts.
+func ValidateAndResolveURLWithOptions(feedURL *url.URL, rawURL string, opts URLOptions) (string, error) {
 	rawURL = strings.TrimSpace(rawURL)
 	if rawURL == "" {
 		return "", ErrInvalidURL
 	}
 
-	parsed, err := url.Parse(rawURL)
+	if opts.UnescapeEntities && strings.Contains(rawURL, "&") {
+		rawURL = unescapeEntities(rawURL)
+	}
+
+	parsed, err := url.Parse(normalizeSlashes(rawURL))
 	if err != nil {
 		return "", fmt.Errorf("%w: %s", ErrInvalidURL, err)
 	}
 
-	return feedURL.ResolveReference(parsed).String(), nil
+	base := feedURL
+	if opts.Base != nil {
+		base = opts.Base
+	}
+	if parsed.Scheme == "" && parsed.Host != "" && feedURL.Scheme != "" {
+		// Protocol-relative links take the scheme the feed was fetched
+		// over, even when an xml:base downgrades it, so an https feed
+		// never yields mixed content
+		parsed.Scheme = feedURL.Scheme
+	}
+	resolved := base.ResolveReference(parsed)
+	if opts.RejectCrossDomain && !sameOrigin(resolved.Hostname(), feedURL.Hostname(), opts.SameSite) {
+		return "", fmt.Errorf("%w: %s", ErrCrossDomainLink, resolved.Host)
+	}
+
+	result := resolved.String()
+	if opts.MaxLength > 0 && len(result) > opts.MaxLength {
+		return "", fmt.Errorf("%w: %d bytes, limit %d", ErrURLTooLong, len(result), opts.MaxLength)
+	}
+	return result, nil
+}
+
+// entityRef matches a terminated HTML character reference. Browsers also
+// accept legacy references without the semicolon, such as &reg, which would
+// eat the start of query parameters.
+var entityRef = regexp.MustCompile(`&(?:#[0-9]+|#[xX][0-9a-fA-F]+|[a-zA-Z][a-zA-Z0-9]*);`)
+
+// unescapeEntities decodes the terminated character references in s.
+func unescapeEntities(s string) string {
+	return entityRef.ReplaceAllStringFunc(s, html.UnescapeString)
+}
+
+// sameOrigin reports whether host is the feed's host or, with sameSite, has
+// the same registrable domain.
+func sameOrigin(host, feedHost string, sameSite bool) bool {
+	if sameSite {
+		return domain.Registrable(host) == domain.Registrable(feedHost)
+	}
+	return strings.EqualFold(host, feedHost)
+}
+
+// normalizeSlashes turns backslashes before any query or fragment into
+// forward slashes, as browsers do for http URLs. Otherwise a link such as
+// "/\evil.com" would resolve to a path on the feed's host here but open
+// evil.com when followed.
+func normalizeSlashes(rawURL string) string {
+	end := strings.IndexAny(rawURL, "?#")
+	if end < 0 {
+		end = len(rawURL)
+	}
+	if !strings.Contains(rawURL[:end], `\`) {
+		return rawURL
+	}
+	return strings.ReplaceAll(rawURL[:end], `\`, "/") + rawURL[end:]
 }
 
-var spaceRegexp = regexp.MustCompile(`\s+`)
+// IsAbsoluteHTTPURL reports whether rawURL is an absolute http or https url.
+func IsAbsoluteHTTPURL(rawURL string) bool {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
 
 // ValidateAndSanitizeHeadline cleans and validates the item headline.
 // Extracted as a package function for better testability.
@@ -48,52 +154,251 @@ func ValidateAndSanitizeHeadline(rawHeadline string, maxLength int) (string, err
 	}
 
 	// Normalize whitespace and trim
-	headline := spaceRegexp.ReplaceAllString(rawHeadline, " ")
+	headline := collapseSpace(rawHeadline)
 	headline = strings.TrimSpace(headline)
 
 	// Check length (using rune count for proper Unicode handling)
-	if len([]rune(headline)) > maxLength {
+	if utf8.RuneCountInString(headline) > maxLength {
 		return "", ErrHeadlineTooLong
 	}
 
 	return headline, nil
 }
 
+// collapseSpace replaces each run of whitespace in s with a single space,
+// exactly as regexp.MustCompile(`\s+`).ReplaceAllString(s, " ") would but in
+// a single pass. Go's \s only matches \t, \n, \f, \r and the space, not \v
+// or any non-ASCII space; those bytes never occur inside a multi-byte UTF-8
+// sequence, so s can be scanned byte by byte. s is returned as is, without
+// allocating, when there is nothing to replace.
+func collapseSpace(s string) string {
+	i := 0
+	for ; i < len(s); i++ {
+		if isSpace(s[i]) && (s[i] != ' ' || i+1 < len(s) && isSpace(s[i+1])) {
+			break
+		}
+	}
+	if i == len(s) {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	b.WriteString(s[:i])
+	for i < len(s) {
+		if !isSpace(s[i]) {
+			start := i
+			for i < len(s) && !isSpace(s[i]) {
+				i++
+			}
+			b.WriteString(s[start:i])
+			continue
+		}
+		b.WriteByte(' ')
+		for i < len(s) && isSpace(s[i]) {
+			i++
+		}
+	}
+	return b.String()
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// DateOptions controls how ValidatePublicationDateWithOptions parses and
+// bounds publication dates.
+type DateOptions struct {
+	MaxAge          time.Duration // Zero or less disables the age check
+	FutureTolerance time.Duration // Zero rejects any date after the current time
+	MaxDateLength   int           // Longer date strings are rejected unparsed; zero uses the dateparser default
+	MinDate         time.Time     // Earlier dates are rejected as too old; zero disables the check
+	MaxDate         time.Time     // Later dates are rejected as future; zero disables the check
+	DisableFallback bool          // Use only gofeed's PublishedParsed, never the dateparser; Location is then ignored
+
+	// Location is the time zone of dates that carry none; nil means UTC.
+	Location *time.Location
+}
+
 // ValidatePublicationDate validates that the item's publication date is within acceptable bounds.
+// A maxAge of zero or less disables the age check. A futureTolerance of zero
+// rejects any date after the current time. The zero time and the Unix epoch,
+// which broken feeds emit for unknown dates, count as a missing date. The
+// item is not modified; the parsed date is only returned. It is returned
+// along with ErrPublicationTooOld too, for callers that keep old items.
 // Extracted as a package function for better testability.
 func ValidatePublicationDate(item *gofeed.Item, maxAge, futureTolerance time.Duration) (time.Time, error) {
-	if item.PublishedParsed == nil {
-		if pubDate := item.Published; pubDate == "" {
-			return time.Time{}, ErrMissingPublishDate
-		} else if t, err := dateparser.ParseDateWithDefaultTZ(pubDate); err == nil {
-			item.PublishedParsed = &t
+	return ValidatePublicationDateWithOptions(item, DateOptions{
+		MaxAge:          maxAge,
+		FutureTolerance: futureTolerance,
+	})
+}
+
+// ValidatePublicationDateWithOptions is like ValidatePublicationDate but
+// takes its settings from opts.
+func ValidatePublicationDateWithOptions(item *gofeed.Item, opts DateOptions) (time.Time, error) {
+	// Never store the parsed date on item; it may be shared with other goroutines
+	var parsed time.Time
+	if item.PublishedParsed != nil {
+		parsed = *item.PublishedParsed
+
+		// gofeed reads dates without a zone as UTC; parse them again to
+		// place them in opts.Location instead
+		if opts.Location != nil && item.Published != "" && !opts.DisableFallback {
+			if t, err := parseDate(item.Published, opts); err == nil {
+				parsed = t
+			}
+		}
+	} else if opts.DisableFallback {
+		return time.Time{}, ErrMissingPublishDate
+	} else {
+		var err error
+		if pubDate := item.Published; pubDate != "" {
+			parsed, err = parseDate(pubDate, opts)
+		} else if dcDate := dublinCoreDate(item); dcDate != "" {
+			parsed, err = parseW3CDate(dcDate, opts)
 		} else {
+			return time.Time{}, ErrMissingPublishDate
+		}
+
+		if err != nil {
 			return time.Time{}, fmt.Errorf("%w: %s", ErrFeedPublicationDateFormat, err)
 		}
 	}
 
+	// Placeholders for an unknown date, not real publication times
+	if parsed.IsZero() || parsed.Unix() == 0 {
+		return time.Time{}, ErrMissingPublishDate
+	}
+
 	now := time.Now().UTC()
-	pubDate := item.PublishedParsed.UTC()
+	pubDate := parsed.UTC()
 
 	// Check if the publication date is too far in the future
-	if pubDate.Sub(now) > futureTolerance {
+	if pubDate.Sub(now) > opts.FutureTolerance {
 		return time.Time{}, ErrFuturePublication
 	}
+	if !opts.MaxDate.IsZero() && pubDate.After(opts.MaxDate) {
+		return time.Time{}, ErrAfterMaxDate
+	}
 
 	// Check if the publication date is too old
-	if now.Sub(pubDate) > maxAge {
-		return time.Time{}, ErrPublicationTooOld
+	if opts.MaxAge > 0 && now.Sub(pubDate) > opts.MaxAge {
+		return pubDate, ErrPublicationTooOld
+	}
+	if !opts.MinDate.IsZero() && pubDate.Before(opts.MinDate) {
+		return pubDate, ErrPublicationTooOld
 	}
 
 	return pubDate, nil
 }
 
-// ExtractContent gets the best available content from an item.
-// Extracted as a package function for better testability.
-func ExtractContent(item *gofeed.Item) string {
-	// First try description, then fallback to content
-	if content := strings.TrimSpace(item.Description); content != "" {
-		return content
+// minSkewItems is the number of future-dated items FeedClockSkew needs
+// before it trusts them to show a skewed clock rather than one odd date.
+const minSkewItems = 2
+
+// FeedClockSkew estimates how far ahead of now the clock of the feed that
+// published items runs, for feeds whose newest items are consistently dated
+// a little in the future. It considers the dates gofeed parsed that lie
+// after now by no more than window; items dated further ahead are ignored.
+// When at least two items qualify, so that each confirms the others, the
+// skew is the median of their offsets from now. Otherwise, or when window
+// is not positive, it is zero.
+func FeedClockSkew(items []*gofeed.Item, now time.Time, window time.Duration) time.Duration {
+	if window <= 0 {
+		return 0
+	}
+
+	var offsets []time.Duration
+	for _, item := range items {
+		if item == nil || item.PublishedParsed == nil {
+			continue
+		}
+		if offset := item.PublishedParsed.Sub(now); offset > 0 && offset <= window {
+			offsets = append(offsets, offset)
+		}
+	}
+	if len(offsets) < minSkewItems {
+		return 0
+	}
+
+	slices.Sort(offsets)
+	return offsets[(len(offsets)-1)/2]
+}
+
+// RawPublicationDate returns the date string ValidatePublicationDate parses
+// for item when gofeed could not: Published, or failing that a Dublin Core
+// date. It is empty if the item carries no date string.
+func RawPublicationDate(item *gofeed.Item) string {
+	if item.Published != "" {
+		return item.Published
+	}
+	return dublinCoreDate(item)
+}
+
+// dublinCoreDate returns the item's dc:date, or failing that its
+// dcterms:issued, for feeds that carry the date only there. gofeed maps
+// dc:date to Published for RSS but not for Atom, and never reads
+// dcterms:issued.
+func dublinCoreDate(item *gofeed.Item) string {
+	if item.DublinCoreExt != nil {
+		for _, date := range item.DublinCoreExt.Date {
+			if date = strings.TrimSpace(date); date != "" {
+				return date
+			}
+		}
+	}
+
+	for _, field := range [][2]string{{"dc", "date"}, {"dcterms", "issued"}} {
+		for _, e := range item.Extensions[field[0]][field[1]] {
+			if value := strings.TrimSpace(e.Value); value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// w3cLayouts are the W3C date and time formats that Dublin Core dates use.
+var w3cLayouts = []string{time.RFC3339, "2006-01-02T15:04Z07:00", time.DateOnly, "2006-01", "2006"}
+
+// parseW3CDate parses a Dublin Core date. Unlike Published these are never
+// parsed by gofeed, so the common W3C formats are tried before the
+// dateparser fallback layouts.
+func parseW3CDate(s string, opts DateOptions) (time.Time, error) {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range w3cLayouts {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t, nil
+		}
+	}
+	return parseDate(s, opts)
+}
+
+// parseDate parses a date string with the dateparser layouts.
+func parseDate(s string, opts DateOptions) (time.Time, error) {
+	if opts.Location != nil {
+		return dateparser.ParseDateWithLocationMaxLength(s, opts.Location, opts.MaxDateLength)
+	}
+	return dateparser.ParseDateWithDefaultTZMaxLength(s, opts.MaxDateLength)
+}
+
+// SplitContent separates an item's summary from its full content. When the
+// item has both a description and content, as RSS items with
+// content:encoded do, the description is the summary. Otherwise whichever
+// is present is the content and the summary is empty.
+func SplitContent(item *gofeed.Item) (summary, content string) {
+	description := strings.TrimSpace(item.Description)
+	content = strings.TrimSpace(item.Content)
+	switch {
+	case content == "":
+		return "", description
+	case description == content:
+		return "", content
+	default:
+		return description, content
 	}
-	return strings.TrimSpace(item.Content)
 }
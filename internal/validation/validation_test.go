@@ -2,8 +2,13 @@ package validation
 
 import (
 	"net/url"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +57,104 @@ func TestValidateAndSanitizeHeadline(t *testing.T) {
 	}
 }
 
+// regexpHeadline is the regexp-based sanitizer collapseSpace replaced, kept
+// as the reference it must agree with.
+func regexpHeadline(raw string) string {
+	return strings.TrimSpace(regexp.MustCompile(`\s+`).ReplaceAllString(raw, " "))
+}
+
+func TestCollapseSpace_MatchesRegexp(t *testing.T) {
+	inputs := []string{
+		"",
+		" ",
+		"plain",
+		"single space only",
+		"  leading and trailing  ",
+		"tabs\tand\nnewlines\r\n\fmixed \t runs",
+		"vertical\vtab is not \\s",
+		"no-break\u00a0space\u2003em space\u3000ideographic",
+		"\u00a0 \u00a0trimmed unicode\u2028",
+		"héllo\t\twörld 日本\n\n語",
+		"invalid \xff\xfe  utf-8\t",
+		"trailing run \t\n",
+	}
+
+	for _, input := range inputs {
+		want := regexpHeadline(input)
+		got, err := ValidateAndSanitizeHeadline(input, 1000)
+		if input == "" {
+			assert.ErrorIs(t, err, ErrEmptyHeadline)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, want, got, "input %q", input)
+	}
+
+	clean := "already clean"
+	assert.Equal(t, clean, collapseSpace(clean))
+	assert.Zero(t, testing.AllocsPerRun(10, func() { collapseSpace(clean) }), "clean input is not copied")
+}
+
+var benchHeadlines = []string{
+	"Central bank holds rates steady as inflation cools",
+	"  Breaking:\n\tMarkets   rally after\r\nearnings beat  ",
+	"Économie : la croissance ralentit au troisième trimestre",
+}
+
+func BenchmarkValidateAndSanitizeHeadline(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, h := range benchHeadlines {
+			_, _ = ValidateAndSanitizeHeadline(h, 500)
+		}
+	}
+}
+
+// BenchmarkRegexpHeadline measures the regexp-based sanitizer for comparison
+// with BenchmarkValidateAndSanitizeHeadline.
+func BenchmarkRegexpHeadline(b *testing.B) {
+	re := regexp.MustCompile(`\s+`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, h := range benchHeadlines {
+			headline := strings.TrimSpace(re.ReplaceAllString(h, " "))
+			_ = len([]rune(headline)) > 500
+		}
+	}
+}
+
+func TestFeedClockSkew(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	items := func(offsets ...time.Duration) []*gofeed.Item {
+		result := []*gofeed.Item{nil, {Title: "undated"}}
+		for _, offset := range offsets {
+			pub := now.Add(offset)
+			result = append(result, &gofeed.Item{PublishedParsed: &pub})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name   string
+		items  []*gofeed.Item
+		window time.Duration
+		want   time.Duration
+	}{
+		{"disabled", items(10*time.Minute, 20*time.Minute), 0, 0},
+		{"no future items", items(-time.Hour, -2*time.Hour), time.Hour, 0},
+		{"one future item", items(10*time.Minute, -time.Hour), time.Hour, 0},
+		{"median of odd count", items(10*time.Minute, 30*time.Minute, 20*time.Minute, -time.Hour), time.Hour, 20 * time.Minute},
+		{"lower median of even count", items(10*time.Minute, 20*time.Minute), time.Hour, 10 * time.Minute},
+		{"outside window ignored", items(10*time.Minute, 20*time.Minute, 48*time.Hour, 72*time.Hour), time.Hour, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FeedClockSkew(tt.items, now, tt.window))
+		})
+	}
+}
+
 func TestValidateAndResolveURL(t *testing.T) {
 	baseURL, err := url.Parse("https://example.com/feed")
 	require.NoError(t, err)
@@ -98,3 +201,448 @@ func TestValidateAndResolveURL(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAndResolveURL_CrossDomain(t *testing.T) {
+	feedURL, err := url.Parse("https://feeds.example.com/rss")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		rawURL  string
+		want    string
+		wantErr error
+	}{
+		{"relative path", "/article", "https://feeds.example.com/article", nil},
+		{"same host", "https://FEEDS.example.com/a", "https://FEEDS.example.com/a", nil},
+		{"sibling subdomain", "https://www.example.com/a", "", ErrCrossDomainLink},
+		{"protocol relative subdomain", "//evil.example.com/a", "", ErrCrossDomainLink},
+		{"absolute other domain", "https://evil.com/a", "", ErrCrossDomainLink},
+		{"protocol relative", "//evil.com/a", "", ErrCrossDomainLink},
+		{"slash backslash", `/\evil.com/a`, "", ErrCrossDomainLink},
+		{"double backslash", `\\evil.com/a`, "", ErrCrossDomainLink},
+		{"backslash in query kept", `/search?q=a\b`, `https://feeds.example.com/search?q=a\b`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateAndResolveURLWithOptions(feedURL, tt.rawURL, URLOptions{RejectCrossDomain: true})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("same site", func(t *testing.T) {
+		opts := URLOptions{RejectCrossDomain: true, SameSite: true}
+		got, err := ValidateAndResolveURLWithOptions(feedURL, "//www.example.com/a", opts)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://www.example.com/a", got)
+		_, err = ValidateAndResolveURLWithOptions(feedURL, "//evil.com/a", opts)
+		assert.ErrorIs(t, err, ErrCrossDomainLink)
+	})
+
+	t.Run("allowed by default", func(t *testing.T) {
+		got, err := ValidateAndResolveURL(feedURL, `/\evil.com/a`)
+		assert.NoError(t, err)
+		assert.Equal(t, "https://evil.com/a", got, "backslashes resolve the way a browser would")
+	})
+}
+
+func TestValidateAndResolveURL_Base(t *testing.T) {
+	feedURL, err := url.Parse("https://example.com/feeds/rss")
+	require.NoError(t, err)
+	base, err := url.Parse("https://cdn.example.com/blog/")
+	require.NoError(t, err)
+
+	got, err := ValidateAndResolveURLWithOptions(feedURL, "post.html", URLOptions{Base: base})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/blog/post.html", got)
+
+	other, err := url.Parse("https://other.org/")
+	require.NoError(t, err)
+	_, err = ValidateAndResolveURLWithOptions(feedURL, "post.html", URLOptions{Base: other, RejectCrossDomain: true})
+	assert.ErrorIs(t, err, ErrCrossDomainLink, "hosts are compared with the feed url, not the base")
+}
+
+func TestValidateAndResolveURL_ProtocolRelative(t *testing.T) {
+	httpBase, err := url.Parse("http://legacy.example.com/")
+	require.NoError(t, err)
+	httpsBase, err := url.Parse("https://example.com/blog/")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		feedURL string
+		rawURL  string
+		base    *url.URL
+		want    string
+	}{
+		{"https feed", "https://example.com/feed", "//cdn.example.com/x", nil, "https://cdn.example.com/x"},
+		{"http feed", "http://example.com/feed", "//cdn.example.com/x", nil, "http://cdn.example.com/x"},
+		{"uppercase feed scheme", "HTTPS://example.com/feed", "//cdn.example.com/x", nil, "https://cdn.example.com/x"},
+		{"surrounding whitespace", "https://example.com/feed", " //cdn.example.com/x?a=1#top\n", nil, "https://cdn.example.com/x?a=1#top"},
+		{"backslashes", "https://example.com/feed", `\\cdn.example.com\x`, nil, "https://cdn.example.com/x"},
+		{"with port", "https://example.com/feed", "//cdn.example.com:8443/x", nil, "https://cdn.example.com:8443/x"},
+		{"http base in https feed", "https://example.com/feed", "//cdn.example.com/x", httpBase, "https://cdn.example.com/x"},
+		{"https base in http feed", "http://example.com/feed", "//cdn.example.com/x", httpsBase, "http://cdn.example.com/x"},
+		{"relative path keeps base scheme", "https://example.com/feed", "x", httpBase, "http://legacy.example.com/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feedURL, err := url.Parse(tt.feedURL)
+			require.NoError(t, err)
+
+			got, err := ValidateAndResolveURLWithOptions(feedURL, tt.rawURL, URLOptions{Base: tt.base})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateAndResolveURL_UnescapeEntities(t *testing.T) {
+	feedURL, err := url.Parse("https://example.com/feed")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		rawURL   string
+		unescape bool
+		want     string
+	}{
+		{"kept by default", "/search?q=go&amp;page=2", false, "https://example.com/search?q=go&amp;page=2"},
+		{"amp", "/search?q=go&amp;page=2", true, "https://example.com/search?q=go&page=2"},
+		{"numeric", "https://example.com/a?x=1&#38;y=2", true, "https://example.com/a?x=1&y=2"},
+		{"plain ampersand", "/search?q=go&page=2", true, "https://example.com/search?q=go&page=2"},
+		{"percent-encoded untouched", "/a%26b?q=%26amp%3B", true, "https://example.com/a%26b?q=%26amp%3B"},
+		{"hex", "/a?x=1&#x26;y=2", true, "https://example.com/a?x=1&y=2"},
+		{"region parameter", "/a?x=1&region=eu", true, "https://example.com/a?x=1&region=eu"},
+		{"copy parameter", "/a?x=1&copy=2&amp;not=3", true, "https://example.com/a?x=1&copy=2&not=3"},
+		{"unknown reference kept", "/a?x=1&bogus;y=2", true, "https://example.com/a?x=1&bogus;y=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateAndResolveURLWithOptions(feedURL, tt.rawURL, URLOptions{UnescapeEntities: tt.unescape})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateAndResolveURL_MaxLength(t *testing.T) {
+	feedURL, err := url.Parse("https://example.com/feed")
+	require.NoError(t, err)
+	oversized := "/article?q=" + strings.Repeat("x", 1<<20)
+
+	tests := []struct {
+		name      string
+		rawURL    string
+		maxLength int
+		wantErr   error
+	}{
+		{"within limit", "/article", 30, nil},
+		{"exactly at limit", "/article", len("https://example.com/article"), nil},
+		{"measured resolved", "/article", len("https://example.com/article") - 1, ErrURLTooLong},
+		{"oversized query", oversized, 2048, ErrURLTooLong},
+		{"no limit", oversized, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateAndResolveURLWithOptions(feedURL, tt.rawURL, URLOptions{MaxLength: tt.maxLength})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, got)
+		})
+	}
+}
+
+func TestValidatePublicationDate(t *testing.T) {
+	old := time.Now().Add(-365 * 24 * time.Hour)
+
+	tests := []struct {
+		name    string
+		pubDate time.Time
+		maxAge  time.Duration
+		wantErr error
+	}{
+		{"within max age", time.Now().Add(-time.Hour), 24 * time.Hour, nil},
+		{"too old", old, 24 * time.Hour, ErrPublicationTooOld},
+		{"zero max age disables limit", old, 0, nil},
+		{"negative max age disables limit", old, -1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &gofeed.Item{PublishedParsed: &tt.pubDate}
+			got, err := ValidatePublicationDate(item, tt.maxAge, time.Hour)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, got.Equal(tt.pubDate))
+		})
+	}
+}
+
+func TestValidatePublicationDate_TooOldReturnsDate(t *testing.T) {
+	old := time.Now().Add(-48 * time.Hour).UTC().Truncate(time.Second)
+	item := &gofeed.Item{PublishedParsed: &old}
+
+	got, err := ValidatePublicationDate(item, 24*time.Hour, time.Hour)
+	assert.ErrorIs(t, err, ErrPublicationTooOld)
+	assert.True(t, got.Equal(old), "got %v, want %v", got, old)
+
+	got, err = ValidatePublicationDateWithOptions(item, DateOptions{MinDate: time.Now()})
+	assert.ErrorIs(t, err, ErrPublicationTooOld)
+	assert.True(t, got.Equal(old), "got %v, want %v", got, old)
+}
+
+func TestValidatePublicationDate_FutureTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		offset    time.Duration
+		tolerance time.Duration
+		wantErr   error
+	}{
+		{"zero tolerance accepts past", -time.Millisecond, 0, nil},
+		{"zero tolerance rejects future", time.Second, 0, ErrFuturePublication},
+		{"inside tolerance", 59 * time.Minute, time.Hour, nil},
+		{"beyond tolerance", 61 * time.Minute, time.Hour, ErrFuturePublication},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pubDate := time.Now().Add(tt.offset)
+			item := &gofeed.Item{PublishedParsed: &pubDate}
+			_, err := ValidatePublicationDate(item, 24*time.Hour, tt.tolerance)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidatePublicationDate_MinDate(t *testing.T) {
+	floor := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		pubDate time.Time
+		maxAge  time.Duration
+		wantErr error
+	}{
+		{"after floor", floor.Add(time.Hour), 0, nil},
+		{"exactly at floor", floor, 0, nil},
+		{"before floor", floor.Add(-time.Second), 0, ErrPublicationTooOld},
+		{"before floor in another zone", time.Date(2019, 12, 31, 23, 0, 0, 0, time.FixedZone("", -30*60)), 0, ErrPublicationTooOld},
+		{"after floor but beyond max age", floor.Add(time.Hour), 24 * time.Hour, ErrPublicationTooOld},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &gofeed.Item{PublishedParsed: &tt.pubDate}
+			_, err := ValidatePublicationDateWithOptions(item, DateOptions{MaxAge: tt.maxAge, MinDate: floor})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidatePublicationDate_MaxDate(t *testing.T) {
+	ceiling := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		pubDate   time.Time
+		tolerance time.Duration
+		wantErr   error
+	}{
+		{"before ceiling", ceiling.Add(-time.Minute), 0, nil},
+		{"exactly at ceiling", ceiling, 0, nil},
+		{"after ceiling within drift tolerance", ceiling.Add(time.Minute), 24 * time.Hour, ErrFuturePublication},
+		{"beyond drift tolerance", time.Now().Add(2 * time.Hour), time.Hour, ErrFuturePublication},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := &gofeed.Item{PublishedParsed: &tt.pubDate}
+			_, err := ValidatePublicationDateWithOptions(item, DateOptions{
+				MaxAge:          48 * time.Hour,
+				FutureTolerance: tt.tolerance,
+				MaxDate:         ceiling,
+			})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestValidatePublicationDate_DublinCore(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	dcDate := map[string][]ext.Extension{"date": {{Name: "date", Value: recent.Format(time.RFC3339)}}}
+	issued := map[string][]ext.Extension{"issued": {{Name: "issued", Value: recent.Format(time.RFC1123Z)}}}
+
+	tests := []struct {
+		name    string
+		item    *gofeed.Item
+		wantErr error
+	}{
+		{"dc:date extension", &gofeed.Item{Extensions: ext.Extensions{"dc": dcDate}}, nil},
+		{"dc:date via DublinCoreExt", &gofeed.Item{DublinCoreExt: &ext.DublinCoreExtension{Date: []string{recent.Format(time.RFC3339)}}}, nil},
+		{"dcterms:issued", &gofeed.Item{Extensions: ext.Extensions{"dcterms": issued}}, nil},
+		{"unparseable dc:date", &gofeed.Item{Extensions: ext.Extensions{"dc": {"date": {{Value: "someday"}}}}}, ErrFeedPublicationDateFormat},
+		{"no date anywhere", &gofeed.Item{}, ErrMissingPublishDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidatePublicationDate(tt.item, 24*time.Hour, time.Hour)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, got.Equal(recent), "got %v, want %v", got, recent)
+		})
+	}
+}
+
+func TestValidatePublicationDate_DoesNotModifyItem(t *testing.T) {
+	pubDate := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	item := &gofeed.Item{
+		Published:  pubDate.Format(time.RFC1123Z),
+		Extensions: ext.Extensions{"dc": {"date": {{Name: "date", Value: "unused"}}}},
+	}
+	before := *item
+
+	got, err := ValidatePublicationDate(item, 24*time.Hour, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(pubDate))
+	assert.Equal(t, before, *item)
+	assert.Nil(t, item.PublishedParsed)
+}
+
+func TestSplitContent(t *testing.T) {
+	tests := []struct {
+		name        string
+		item        *gofeed.Item
+		wantSummary string
+		wantContent string
+	}{
+		{"both", &gofeed.Item{Description: "Summary", Content: "Full body"}, "Summary", "Full body"},
+		{"description only", &gofeed.Item{Description: " Body "}, "", "Body"},
+		{"content only", &gofeed.Item{Content: "Body"}, "", "Body"},
+		{"identical", &gofeed.Item{Description: "Body", Content: "Body "}, "", "Body"},
+		{"neither", &gofeed.Item{}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, content := SplitContent(tt.item)
+			assert.Equal(t, tt.wantSummary, summary)
+			assert.Equal(t, tt.wantContent, content)
+		})
+	}
+}
+
+func TestValidatePublicationDate_Location(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	local := time.Now().Add(-2 * time.Hour).In(loc).Truncate(time.Second)
+	zoneless := local.Format("Mon, 02 Jan 2006 15:04:05")
+	asUTC := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), 0, time.UTC)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	tests := []struct {
+		name string
+		item *gofeed.Item
+		loc  *time.Location
+		want time.Time
+	}{
+		{"unparsed, default UTC", &gofeed.Item{Published: zoneless}, nil, asUTC},
+		{"unparsed, location", &gofeed.Item{Published: zoneless}, loc, local},
+		{"parsed by gofeed as UTC", &gofeed.Item{Published: zoneless, PublishedParsed: &asUTC}, loc, local},
+		{"explicit offset kept", &gofeed.Item{Published: local.Format(time.RFC1123Z)}, time.FixedZone("", -5*3600), local},
+		{"dc:date", &gofeed.Item{Extensions: ext.Extensions{"dc": {"date": {{Value: local.Format(time.DateOnly)}}}}}, loc, midnight},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidatePublicationDateWithOptions(tt.item, DateOptions{Location: tt.loc})
+			require.NoError(t, err)
+			assert.True(t, got.Equal(tt.want), "got %v, want %v", got, tt.want.UTC())
+		})
+	}
+}
+
+func TestValidatePublicationDate_DisableFallback(t *testing.T) {
+	recent := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	loc := time.FixedZone("CET", 3600)
+	opts := DateOptions{DisableFallback: true, Location: loc}
+
+	t.Run("parsed by gofeed", func(t *testing.T) {
+		zoneless := recent.Format("2006-01-02 15:04:05")
+		got, err := ValidatePublicationDateWithOptions(&gofeed.Item{Published: zoneless, PublishedParsed: &recent}, opts)
+		require.NoError(t, err)
+		assert.True(t, got.Equal(recent), "not parsed again for Location")
+	})
+
+	tests := []struct {
+		name string
+		item *gofeed.Item
+	}{
+		{"parseable Published", &gofeed.Item{Published: recent.Format(time.RFC1123Z)}},
+		{"unparseable Published", &gofeed.Item{Published: "someday"}},
+		{"dc:date", &gofeed.Item{Extensions: ext.Extensions{"dc": {"date": {{Value: recent.Format(time.RFC3339)}}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ValidatePublicationDateWithOptions(tt.item, opts)
+			assert.ErrorIs(t, err, ErrMissingPublishDate, "the dateparser is not consulted")
+
+			_, err = ValidatePublicationDateWithOptions(tt.item, DateOptions{})
+			assert.NotErrorIs(t, err, ErrMissingPublishDate)
+		})
+	}
+}
+
+func TestValidatePublicationDate_ZeroDates(t *testing.T) {
+	tests := []struct {
+		name string
+		item *gofeed.Item
+	}{
+		{"zero PublishedParsed", &gofeed.Item{PublishedParsed: &time.Time{}}},
+		{"unix epoch", &gofeed.Item{PublishedParsed: timePtr(time.Unix(0, 0))}},
+		{"epoch string", &gofeed.Item{Published: "Thu, 01 Jan 1970 00:00:00 +0000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, maxAge := range []time.Duration{0, 24 * time.Hour} {
+				_, err := ValidatePublicationDate(tt.item, maxAge, time.Hour)
+				assert.ErrorIs(t, err, ErrMissingPublishDate)
+			}
+		})
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
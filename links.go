@@ -0,0 +1,56 @@
+package feedfetcher
+
+import (
+	"net/url"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+)
+
+// Link is one of the links attached to a feed item, such as the alternate
+// (canonical) page, an enclosure or a comments page.
+type Link struct {
+	Href string
+	Rel  string
+	Type string
+}
+
+// itemLinks collects all links of an item, resolved against base as
+// resolveOptionalURL does. Atom links keep their declared relation; an RSS
+// <link> is reported as "alternate" and enclosures as "enclosure". Invalid
+// and duplicate links are skipped.
+func itemLinks(feedURL, base *url.URL, item *gofeed.Item) []Link {
+	var links []Link
+	seen := make(map[[2]string]struct{})
+
+	add := func(href, rel, linkType string) {
+		href = resolveOptionalURL(feedURL, base, href)
+		if href == "" {
+			return
+		}
+		key := [2]string{href, rel}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		links = append(links, Link{Href: href, Rel: rel, Type: linkType})
+	}
+
+	for _, e := range feedparser.AtomExtensions(item.Extensions, "link") {
+		rel := e.Attrs["rel"]
+		if rel == "" {
+			rel = "alternate"
+		}
+		add(e.Attrs["href"], rel, e.Attrs["type"])
+	}
+
+	add(item.Link, "alternate", "")
+
+	for _, enclosure := range item.Enclosures {
+		if enclosure != nil {
+			add(enclosure.URL, "enclosure", enclosure.Type)
+		}
+	}
+
+	return links
+}
@@ -0,0 +1,41 @@
+package feedfetcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const atomWithLinks = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example</title>
+  <updated>2025-03-23T11:02:13Z</updated>
+  <entry>
+    <title>Entry</title>
+    <id>urn:uuid:1</id>
+    <updated>2025-03-23T11:02:13Z</updated>
+    <link rel="replies" type="text/html" href="/posts/1#comments"/>
+    <link type="text/html" href="/posts/1"/>
+    <link rel="enclosure" type="audio/mpeg" href="https://cdn.example.com/1.mp3"/>
+  </entry>
+</feed>`
+
+func TestItemLinks(t *testing.T) {
+	fetcher := NewDefaultFeedFetcher()
+	data, err := fetcher.parser.Parse(strings.NewReader(atomWithLinks))
+	require.NoError(t, err)
+	require.Len(t, data.Items, 1)
+
+	item := data.Items[0]
+	assert.Equal(t, "/posts/1", item.Link, "link without rel is the alternate link")
+
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	links := itemLinks(feedURL, feedURL, item)
+	assert.Equal(t, []Link{
+		{Href: "https://example.com/posts/1#comments", Rel: "replies", Type: "text/html"},
+		{Href: "https://example.com/posts/1", Rel: "alternate", Type: "text/html"},
+		{Href: "https://cdn.example.com/1.mp3", Rel: "enclosure", Type: "audio/mpeg"},
+	}, links)
+}
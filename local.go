@@ -0,0 +1,83 @@
+package feedfetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// ProcessGzippedFeedData decompresses a gzip-compressed feed document, such as
+// an archived .xml.gz file, and runs it through the same validation pipeline
+// as FetchAndProcess. feedURL is used to resolve relative item links.
+func (f *FeedFetcher) ProcessGzippedFeedData(feedURL string, data []byte) ([]*FeedItem, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, newFeedError(feedURL, PhaseParse, fmt.Errorf("%w: %v", ErrInvalidGzip, err))
+	}
+	defer zr.Close()
+
+	// Decompress fully before parsing so truncated or corrupt archives are
+	// reported as such rather than as an xml syntax error.
+	decompressed, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, newFeedError(feedURL, PhaseParse, fmt.Errorf("%w: %v", ErrInvalidGzip, err))
+	}
+
+	return f.processReader(feedURL, bytes.NewReader(decompressed))
+}
+
+// ParseReader parses a feed document read from r, such as a captured
+// response or standard input, and runs it through the same validation
+// pipeline as FetchAndProcess. feedURL is used to resolve relative item links
+// and to label errors; it may be a local path.
+func (f *FeedFetcher) ParseReader(feedURL string, r io.Reader) ([]*FeedItem, error) {
+	return f.processReader(feedURL, r)
+}
+
+// processReader parses a feed document from r and extracts its items.
+func (f *FeedFetcher) processReader(feedURL string, r io.Reader) ([]*FeedItem, error) {
+	ff, err := f.newFeed(fileURL(feedURL))
+	if err != nil {
+		return nil, err
+	}
+	ff.url = feedURL // errors report the location as given
+
+	data, err := f.parser.Parse(r)
+	if err != nil {
+		return nil, newFeedError(feedURL, PhaseParse, err)
+	}
+	ff.data = data
+
+	return f.extractItems(ff)
+}
+
+// fileURL turns a local feed location into a URL that relative item links
+// can be resolved against. Absolute OS paths, including Windows drive and UNC
+// paths, become file URLs, and backslashes in file URLs become slashes.
+// Anything else is returned unchanged.
+func fileURL(feedURL string) string {
+	if len(feedURL) >= 5 && strings.EqualFold(feedURL[:5], "file:") {
+		return strings.ReplaceAll(feedURL, `\`, "/")
+	}
+	if !filepath.IsAbs(feedURL) {
+		return feedURL
+	}
+
+	path := filepath.ToSlash(feedURL)
+	u := &url.URL{Scheme: "file", Path: path}
+
+	if vol := filepath.VolumeName(feedURL); strings.HasPrefix(vol, `\\`) {
+		// \\host\share\feed.xml -> file://host/share/feed.xml
+		host, rest, _ := strings.Cut(strings.TrimPrefix(path, "//"), "/")
+		u.Host, u.Path = host, "/"+rest
+	} else if !strings.HasPrefix(path, "/") {
+		// C:/feeds/feed.xml -> file:///C:/feeds/feed.xml
+		u.Path = "/" + path
+	}
+
+	return u.String()
+}
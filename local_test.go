@@ -0,0 +1,107 @@
+package feedfetcher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedFetcher_ProcessGzippedFeedData(t *testing.T) {
+	fetcher := NewDefaultFeedFetcher()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(rssDocument(time.Now(), "First", "Second")))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+	compressed := buf.Bytes()
+
+	t.Run("valid archive", func(t *testing.T) {
+		items, err := fetcher.ProcessGzippedFeedData("https://example.com/feed", compressed)
+		assert.NoError(t, err)
+		assert.Len(t, items, 2)
+	})
+
+	t.Run("not gzip", func(t *testing.T) {
+		_, err := fetcher.ProcessGzippedFeedData("https://example.com/feed", []byte("<rss></rss>"))
+		assert.ErrorIs(t, err, ErrInvalidGzip)
+	})
+
+	t.Run("truncated archive", func(t *testing.T) {
+		_, err := fetcher.ProcessGzippedFeedData("https://example.com/feed", compressed[:len(compressed)-10])
+		assert.ErrorIs(t, err, ErrInvalidGzip)
+
+		var feedErr *FeedError
+		if assert.True(t, errors.As(err, &feedErr)) {
+			assert.Equal(t, PhaseParse, feedErr.Phase)
+		}
+	})
+}
+
+func TestFeedFetcher_ParseReader(t *testing.T) {
+	fetcher := NewDefaultFeedFetcher()
+
+	items, err := fetcher.ParseReader("https://example.com/feed", strings.NewReader(rssDocument(time.Now(), "First", "Second")))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "https://example.com/feed", items[0].FeedURL)
+
+	_, err = fetcher.ParseReader("-", strings.NewReader("not a feed"))
+	var feedErr *FeedError
+	require.ErrorAs(t, err, &feedErr)
+	assert.Equal(t, PhaseParse, feedErr.Phase)
+	assert.Equal(t, "-", feedErr.FeedURL)
+}
+
+func TestFileURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+		goos  string // only run on this OS when set
+	}{
+		{"http url unchanged", "https://example.com/feed", "https://example.com/feed", ""},
+		{"relative path unchanged", "feeds/feed.xml", "feeds/feed.xml", ""},
+		{"file url with backslashes", `file:///C:\feeds\feed.xml`, "file:///C:/feeds/feed.xml", ""},
+		{"unix path", "/var/feeds/feed.xml", "file:///var/feeds/feed.xml", "linux"},
+		{"drive letter path", `C:\feeds\feed.xml`, "file:///C:/feeds/feed.xml", "windows"},
+		{"drive letter forward slashes", "C:/feeds/feed.xml", "file:///C:/feeds/feed.xml", "windows"},
+		{"unc path", `\\server\share\feed.xml`, "file://server/share/feed.xml", "windows"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.goos != "" && tt.goos != runtime.GOOS {
+				t.Skipf("path form only exists on %s", tt.goos)
+			}
+			assert.Equal(t, tt.want, fileURL(tt.input))
+		})
+	}
+}
+
+func TestFeedFetcher_ProcessGzippedFeedData_LocalPath(t *testing.T) {
+	path, base := "/var/feeds/feed.xml.gz", "file:///var/feeds/"
+	if runtime.GOOS == "windows" {
+		path, base = `C:\feeds\feed.xml.gz`, "file:///C:/feeds/"
+	}
+
+	doc := strings.Replace(rssDocument(time.Now(), "First"), "https://example.com/0", "1", 1)
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, err := zw.Write([]byte(doc))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	items, err := NewDefaultFeedFetcher().ProcessGzippedFeedData(path, buf.Bytes())
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, base+"1", items[0].URL)
+	assert.Equal(t, base+"feed.xml.gz", items[0].FeedURL)
+}
@@ -0,0 +1,72 @@
+package feedfetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestItemMedia(t *testing.T) {
+	feed, err := gofeed.NewParser().ParseString(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:m="http://search.yahoo.com/mrss/">
+<channel>
+<title>Videos</title>
+<item>
+  <title>Clip</title>
+  <m:content url="/clip.mp4" type="video/mp4" width="1920" height="1080" bitrate="4500.5" duration="61.5"/>
+  <m:group>
+    <m:content url="/clip-360.webm" type="video/webm" width="640" height="360"/>
+    <m:content url="/clip.mp4"/>
+    <m:content type="video/mp4"/>
+    <m:content url="/clip-audio.m4a" bitrate="abc" duration="-3" width=""/>
+    <m:thumbnail url="/clip.jpg"/>
+  </m:group>
+</item>
+<item><title>No media</title></item>
+</channel>
+</rss>`)
+	require.NoError(t, err)
+	base := mustParseURL(t, "https://videos.example.com/feed")
+
+	assert.Equal(t, []MediaContent{
+		{URL: "https://videos.example.com/clip.mp4", Type: "video/mp4", Width: 1920, Height: 1080, Bitrate: 4500, Duration: 61500 * time.Millisecond},
+		{URL: "https://videos.example.com/clip-360.webm", Type: "video/webm", Width: 640, Height: 360},
+		{URL: "https://videos.example.com/clip-audio.m4a"},
+	}, itemMedia(base, base, feed.Items[0]))
+	assert.Nil(t, itemMedia(base, base, feed.Items[1]))
+}
+
+func TestFeedFetcher_MediaGroupInAtom(t *testing.T) {
+	published := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+<title>Channel</title>
+<entry>
+  <title>Video</title>
+  <link rel="alternate" href="https://www.example.com/watch?v=1"/>
+  <published>%s</published>
+  <media:group>
+    <media:title>Video</media:title>
+    <media:content url="https://www.example.com/v/1" type="application/x-shockwave-flash" width="640" height="390"/>
+    <media:thumbnail url="https://i.example.com/1.jpg" width="480" height="360"/>
+  </media:group>
+</entry>
+</feed>`, published)
+	}))
+	defer server.Close()
+
+	items, err := NewDefaultFeedFetcher().FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, []MediaContent{
+		{URL: "https://www.example.com/v/1", Type: "application/x-shockwave-flash", Width: 640, Height: 390},
+	}, items[0].Media)
+}
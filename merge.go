@@ -0,0 +1,156 @@
+package feedfetcher
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// DedupKey selects the item fields MergeItemsWithOptions compares to find
+// duplicates. Keys can be combined; items matching on any of them are
+// duplicates.
+type DedupKey int
+
+const (
+	DedupByURL      DedupKey = 1 << iota // CanonicalURL, or URL without one, ignoring scheme, "www.", fragment and trailing slash
+	DedupByGUID                          // GUID, when not empty and not flagged DuplicateGUID
+	DedupByHeadline                      // Headline, case-insensitively
+
+	DedupByAll = DedupByURL | DedupByGUID | DedupByHeadline
+)
+
+// SortOrder is the order of the items returned by MergeItemsWithOptions.
+type SortOrder int
+
+const (
+	SortNone        SortOrder = iota // Keep the input order
+	SortNewestFirst                  // By PublishedAt, newest first
+	SortOldestFirst                  // By PublishedAt, oldest first
+)
+
+// MergeOptions controls MergeItemsWithOptions.
+type MergeOptions struct {
+	DedupBy DedupKey // 0 keeps duplicates
+	Sort    SortOrder
+}
+
+// MergeItems combines sets of items, dropping duplicates by URL, GUID or
+// headline, and sorts them newest first. See MergeItemsWithOptions.
+func MergeItems(sets ...[]*FeedItem) []*FeedItem {
+	return MergeItemsWithOptions(MergeOptions{DedupBy: DedupByAll, Sort: SortNewestFirst}, sets...)
+}
+
+// MergeItemsWithOptions combines sets of items into one slice. When
+// deduplicating, the first occurrence wins, in the order of the sets and
+// then of the items within each set; sorting happens afterwards and is
+// stable, so items published at the same time keep that order too. Nil items
+// are skipped. The items themselves are shared, not copied.
+func MergeItemsWithOptions(opts MergeOptions, sets ...[]*FeedItem) []*FeedItem {
+	total := 0
+	for _, set := range sets {
+		total += len(set)
+	}
+
+	merged := make([]*FeedItem, 0, total)
+	var seen *dedupIndex
+	if opts.DedupBy != 0 {
+		seen = newDedupIndex(opts.DedupBy, total)
+	}
+	for _, set := range sets {
+		for _, item := range set {
+			if item == nil || (seen != nil && !seen.add(item)) {
+				continue
+			}
+			merged = append(merged, item)
+		}
+	}
+
+	switch opts.Sort {
+	case SortNewestFirst:
+		slices.SortStableFunc(merged, func(a, b *FeedItem) int { return b.PublishedAt.Compare(a.PublishedAt) })
+	case SortOldestFirst:
+		slices.SortStableFunc(merged, func(a, b *FeedItem) int { return a.PublishedAt.Compare(b.PublishedAt) })
+	}
+	return merged
+}
+
+// dedupIndex remembers the keys of the items added to it. Maps for keys
+// that are not compared stay nil.
+type dedupIndex struct {
+	urls, guids, headlines map[string]struct{}
+}
+
+func newDedupIndex(keys DedupKey, size int) *dedupIndex {
+	d := &dedupIndex{}
+	if keys&DedupByURL != 0 {
+		d.urls = make(map[string]struct{}, size)
+	}
+	if keys&DedupByGUID != 0 {
+		d.guids = make(map[string]struct{}, size)
+	}
+	if keys&DedupByHeadline != 0 {
+		d.headlines = make(map[string]struct{}, size)
+	}
+	return d
+}
+
+// add records item and reports whether it was new, i.e. shared none of the
+// compared keys with an item added before.
+func (d *dedupIndex) add(item *FeedItem) bool {
+	var urlKey, headlineKey string
+	if d.urls != nil {
+		urlKey = dedupURL(item.URL)
+		if item.CanonicalURL != "" {
+			urlKey = dedupURL(item.CanonicalURL)
+		}
+	}
+	if d.headlines != nil {
+		headlineKey = strings.ToLower(item.Headline)
+	}
+	guidKey := item.GUID
+	if item.DuplicateGUID {
+		// The feed reuses this GUID for different items, so it says
+		// nothing about whether two of them are the same
+		guidKey = ""
+	}
+	keys := [...]struct {
+		set map[string]struct{}
+		key string
+	}{
+		{d.urls, urlKey},
+		{d.guids, guidKey},
+		{d.headlines, headlineKey},
+	}
+
+	for _, k := range keys {
+		if k.set == nil || k.key == "" {
+			continue
+		}
+		if _, ok := k.set[k.key]; ok {
+			return false
+		}
+	}
+	for _, k := range keys {
+		if k.set != nil && k.key != "" {
+			k.set[k.key] = struct{}{}
+		}
+	}
+	return true
+}
+
+// dedupURL normalizes a URL for duplicate detection: the scheme, a leading
+// "www." on the host, the fragment and a trailing slash are ignored, and the
+// host is compared case-insensitively.
+func dedupURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	key := host + path
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
@@ -0,0 +1,88 @@
+package feedfetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeItems(t *testing.T) {
+	now := time.Now()
+	a := []*FeedItem{
+		{URL: "https://example.com/1", GUID: "g1", Headline: "One", PublishedAt: now.Add(-3 * time.Hour)},
+		{URL: "https://example.com/2", GUID: "g2", Headline: "Two", PublishedAt: now.Add(-1 * time.Hour)},
+		nil,
+	}
+	b := []*FeedItem{
+		{URL: "https://www.example.com/1/", GUID: "b1", Headline: "One again", PublishedAt: now},
+		{URL: "https://other.com/x", GUID: "g2", Headline: "Two elsewhere", PublishedAt: now},
+		{URL: "https://other.com/y", GUID: "b3", Headline: "ONE", PublishedAt: now},
+		{URL: "https://other.com/z", GUID: "b4", Headline: "Three", PublishedAt: now.Add(-2 * time.Hour)},
+	}
+
+	headlines := func(items []*FeedItem) []string {
+		var result []string
+		for _, item := range items {
+			result = append(result, item.Headline)
+		}
+		return result
+	}
+
+	assert.Equal(t, []string{"Two", "Three", "One"}, headlines(MergeItems(a, b)))
+
+	tests := []struct {
+		name string
+		opts MergeOptions
+		want []string
+	}{
+		{"keep everything", MergeOptions{}, []string{"One", "Two", "One again", "Two elsewhere", "ONE", "Three"}},
+		{"by url", MergeOptions{DedupBy: DedupByURL}, []string{"One", "Two", "Two elsewhere", "ONE", "Three"}},
+		{"by guid", MergeOptions{DedupBy: DedupByGUID}, []string{"One", "Two", "One again", "ONE", "Three"}},
+		{"by headline", MergeOptions{DedupBy: DedupByHeadline}, []string{"One", "Two", "One again", "Two elsewhere", "Three"}},
+		{"oldest first", MergeOptions{DedupBy: DedupByAll, Sort: SortOldestFirst}, []string{"One", "Three", "Two"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, headlines(MergeItemsWithOptions(tt.opts, a, b)))
+		})
+	}
+
+	assert.Empty(t, MergeItems())
+}
+
+func TestMergeItems_DuplicateGUID(t *testing.T) {
+	broken := []*FeedItem{
+		{URL: "https://example.com/1", GUID: "same", Headline: "First", DuplicateGUID: true},
+		{URL: "https://example.com/2", GUID: "same", Headline: "Second", DuplicateGUID: true},
+	}
+	other := []*FeedItem{{URL: "https://other.com/3", GUID: "same", Headline: "Third"}}
+
+	merged := MergeItemsWithOptions(MergeOptions{DedupBy: DedupByAll}, broken, other)
+	assert.Len(t, merged, 3, "GUIDs flagged as duplicates are not compared")
+
+	merged = MergeItemsWithOptions(MergeOptions{DedupBy: DedupByAll}, broken, broken)
+	assert.Len(t, merged, 2, "the same items are still found by URL")
+}
+
+func TestDedupURL(t *testing.T) {
+	assert.Equal(t, dedupURL("https://example.com/a"), dedupURL("http://WWW.Example.com/a/#frag"))
+	assert.NotEqual(t, dedupURL("https://example.com/a?id=1"), dedupURL("https://example.com/a?id=2"))
+	assert.NotEqual(t, dedupURL("https://example.com/a"), dedupURL("https://example.org/a"))
+}
+
+func TestMergeItems_CanonicalURL(t *testing.T) {
+	original := []*FeedItem{{URL: "https://news.example.com/story", Headline: "Story"}}
+	copies := []*FeedItem{
+		{URL: "https://mirror.example.net/copy", CanonicalURL: "https://www.news.example.com/story/", Headline: "Story (mirror)"},
+		{URL: "https://news.example.com/story", CanonicalURL: "https://news.example.com/other", Headline: "Canonical elsewhere"},
+	}
+
+	merged := MergeItemsWithOptions(MergeOptions{DedupBy: DedupByURL}, original, copies)
+	var headlines []string
+	for _, item := range merged {
+		headlines = append(headlines, item.Headline)
+	}
+	assert.Equal(t, []string{"Story", "Canonical elsewhere"}, headlines, "the canonical url replaces URL as the key")
+}
@@ -0,0 +1,118 @@
+package feedfetcher
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+	"github.com/reddot-watch/feedfetcher/internal/validation"
+)
+
+// FeedMeta holds feed-level metadata. URLs are resolved against the feed url
+// and left empty when the feed does not declare them.
+type FeedMeta struct {
+	Title       string
+	Description string
+	Link        string
+	Language    string
+	ImageURL    string // Feed image or logo
+	IconURL     string // Atom icon, or the site favicon when Config.FaviconFallback is set
+	SelfURL     string // Canonical feed url declared by a rel="self" link
+	ContentType string // Media type the feed was served as, e.g. application/rss+xml; empty if unknown
+	Generator   string // Software that produced the feed, e.g. "WordPress 6.4"
+	FeedType    string // "rss", "atom" or "json"; empty if it cannot be told
+	FeedVersion string // Format version as reported by the parser, e.g. "2.0" for RSS 2.0
+
+	// HubURLs are the WebSub (PubSubHubbub) hubs declared by rel="hub"
+	// links, without duplicates. Together with SelfURL, the topic, they are
+	// what a WebSub subscription needs.
+	HubURLs []string
+}
+
+// FetchFeed is like FetchAndProcess but also returns the feed's metadata.
+func (f *FeedFetcher) FetchFeed(ctx context.Context, feedURL string) (*FeedMeta, []*FeedItem, error) {
+	ff, items, err := f.fetch(ctx, feedURL, FetchOptions{})
+	if ff == nil || ff.data == nil {
+		return nil, nil, err
+	}
+	return f.newFeedMeta(ff), items, err
+}
+
+func (f *FeedFetcher) newFeedMeta(feed *feed) *FeedMeta {
+	data := feed.data
+	meta := &FeedMeta{
+		Title:       data.Title,
+		Description: data.Description,
+		Link:        resolveOptionalURL(feed.parsedURL, feed.parsedURL, data.Link),
+		Language:    data.Language,
+		SelfURL:     resolveOptionalURL(feed.parsedURL, feed.parsedURL, data.FeedLink),
+		ContentType: feed.contentType,
+		Generator:   strings.TrimSpace(data.Generator),
+		FeedType:    data.FeedType,
+		FeedVersion: feedVersion(data.FeedVersion),
+	}
+	if meta.FeedType == "" {
+		// Custom parsers may not set the type; the served media type is the
+		// next best signal
+		meta.FeedType = feedTypeFromContentType(feed.contentType)
+	}
+
+	for _, link := range feedparser.AtomExtensions(data.Extensions, "link") {
+		if link.Attrs["rel"] != "hub" {
+			continue
+		}
+		if hub := resolveOptionalURL(feed.parsedURL, feed.parsedURL, link.Attrs["href"]); hub != "" && !slices.Contains(meta.HubURLs, hub) {
+			meta.HubURLs = append(meta.HubURLs, hub)
+		}
+	}
+
+	if data.Image != nil {
+		meta.ImageURL = resolveOptionalURL(feed.parsedURL, feed.parsedURL, data.Image.URL)
+	}
+
+	if icons := feedparser.AtomExtensions(data.Extensions, "icon"); len(icons) > 0 {
+		meta.IconURL = resolveOptionalURL(feed.parsedURL, feed.parsedURL, icons[0].Value)
+	}
+	if meta.IconURL == "" && f.config.FaviconFallback {
+		meta.IconURL = faviconURL(feed.parsedURL, meta.Link)
+	}
+
+	return meta
+}
+
+// feedVersion returns the bare version number gofeed reports, which for JSON
+// Feed is the spec url (https://jsonfeed.org/version/1.1).
+func feedVersion(version string) string {
+	if i := strings.LastIndex(version, "/version/"); i >= 0 {
+		return version[i+len("/version/"):]
+	}
+	return strings.TrimSpace(version)
+}
+
+// resolveOptionalURL resolves rawURL against base, which may be an xml:base
+// declared in feedURL, returning an empty string when rawURL is empty or
+// invalid. Protocol-relative links take feedURL's scheme, as item links do.
+func resolveOptionalURL(feedURL, base *url.URL, rawURL string) string {
+	resolved, err := validation.ValidateAndResolveURLWithOptions(feedURL, rawURL, validation.URLOptions{Base: base})
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
+
+// faviconURL derives the conventional /favicon.ico location from the site
+// link, or from the feed url when the feed has no link.
+func faviconURL(feedURL *url.URL, siteLink string) string {
+	base := feedURL
+	if siteLink != "" {
+		if u, err := url.Parse(siteLink); err == nil && u.Host != "" {
+			base = u
+		}
+	}
+	if base == nil || base.Host == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: base.Scheme, Host: base.Host, Path: "/favicon.ico"}).String()
+}
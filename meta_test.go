@@ -0,0 +1,214 @@
+package feedfetcher
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const atomWithImages = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example</title>
+  <link href="https://example.com/"/>
+  <logo>/images/logo.png</logo>
+  <icon>https://cdn.example.com/icon.png</icon>
+  <updated>2025-03-23T11:02:13Z</updated>
+</feed>`
+
+const rssWithoutImages = `<?xml version="1.0"?>
+<rss version="2.0"><channel>
+  <title>Example</title>
+  <link>https://www.example.org/news</link>
+</channel></rss>`
+
+func TestFeedFetcher_newFeedMeta(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		fallback  bool
+		wantImage string
+		wantIcon  string
+	}{
+		{"atom logo and icon", atomWithImages, false, "https://example.com/images/logo.png", "https://cdn.example.com/icon.png"},
+		{"no images", rssWithoutImages, false, "", ""},
+		{"favicon fallback", rssWithoutImages, true, "", "https://www.example.org/favicon.ico"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.FaviconFallback = tt.fallback
+			fetcher := NewFeedFetcher(config)
+
+			ff, err := fetcher.newFeed("https://example.com/feeds/main.xml")
+			require.NoError(t, err)
+			ff.data, err = fetcher.parser.Parse(strings.NewReader(tt.doc))
+			require.NoError(t, err)
+
+			meta := fetcher.newFeedMeta(ff)
+			assert.Equal(t, "Example", meta.Title)
+			assert.Equal(t, tt.wantImage, meta.ImageURL)
+			assert.Equal(t, tt.wantIcon, meta.IconURL)
+		})
+	}
+}
+
+func TestFeedFetcher_newFeedMeta_SelfURL(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			name: "atom self link",
+			doc: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Example</title>
+				<link rel="self" href="/feeds/canonical.xml"/></feed>`,
+			want: "https://example.com/feeds/canonical.xml",
+		},
+		{
+			name: "rss atom:link self",
+			doc: `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Example</title>
+				<atom:link href="https://feeds.example.net/main" rel="self" type="application/rss+xml"/></channel></rss>`,
+			want: "https://feeds.example.net/main",
+		},
+		{
+			name: "absent",
+			doc:  rssWithoutImages,
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewDefaultFeedFetcher()
+			ff, err := fetcher.newFeed("https://example.com/feeds/alias.xml")
+			require.NoError(t, err)
+			ff.data, err = fetcher.parser.Parse(strings.NewReader(tt.doc))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, fetcher.newFeedMeta(ff).SelfURL)
+		})
+	}
+}
+
+func TestFeedFetcher_newFeedMeta_HubURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "atom hubs",
+			doc: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Example</title>
+				<link rel="self" href="/feeds/main.xml"/>
+				<link rel="hub" href="https://pubsubhubbub.appspot.com/"/>
+				<link rel="hub" href="/hub"/>
+				<link rel="hub" href="https://example.com/hub"/></feed>`,
+			want: []string{"https://pubsubhubbub.appspot.com/", "https://example.com/hub"},
+		},
+		{
+			name: "rss atom:link hub",
+			doc: `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom"><channel><title>Example</title>
+				<atom:link href="https://example.com/feeds/main.xml" rel="self"/>
+				<atom:link href="https://hub.example.net/" rel="hub"/></channel></rss>`,
+			want: []string{"https://hub.example.net/"},
+		},
+		{
+			name: "absent",
+			doc:  atomWithImages,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewDefaultFeedFetcher()
+			ff, err := fetcher.newFeed("https://example.com/feeds/main.xml")
+			require.NoError(t, err)
+			ff.data, err = fetcher.parser.Parse(strings.NewReader(tt.doc))
+			require.NoError(t, err)
+
+			meta := fetcher.newFeedMeta(ff)
+			assert.Equal(t, tt.want, meta.HubURLs)
+			if tt.want != nil {
+				assert.Equal(t, "https://example.com/feeds/main.xml", meta.SelfURL)
+			}
+		})
+	}
+}
+
+func TestFeedFetcher_newFeedMeta_Generator(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want string
+	}{
+		{
+			"rss",
+			`<rss version="2.0"><channel><title>Example</title>
+				<generator> https://wordpress.org/?v=6.4 </generator></channel></rss>`,
+			"https://wordpress.org/?v=6.4",
+		},
+		{
+			"atom",
+			`<feed xmlns="http://www.w3.org/2005/Atom"><title>Example</title>
+				<generator uri="https://gohugo.io/" version="0.120.0">Hugo</generator></feed>`,
+			"Hugo v0.120.0 https://gohugo.io/",
+		},
+		{"none", rssWithoutImages, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewDefaultFeedFetcher()
+			ff, err := fetcher.newFeed("https://example.com/feed")
+			require.NoError(t, err)
+			ff.data, err = fetcher.parser.Parse(strings.NewReader(tt.doc))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.want, fetcher.newFeedMeta(ff).Generator)
+		})
+	}
+}
+
+func TestFeedFetcher_newFeedMeta_FeedType(t *testing.T) {
+	tests := []struct {
+		name        string
+		doc         string
+		wantType    string
+		wantVersion string
+	}{
+		{"rss", rssWithoutImages, "rss", "2.0"},
+		{"atom", atomWithImages, "atom", "1.0"},
+		{"json", `{"version": "https://jsonfeed.org/version/1.1", "title": "Example"}`, "json", "1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewDefaultFeedFetcher()
+			ff, err := fetcher.newFeed("https://example.com/feed")
+			require.NoError(t, err)
+			ff.data, err = fetcher.parser.Parse(strings.NewReader(tt.doc))
+			require.NoError(t, err)
+
+			meta := fetcher.newFeedMeta(ff)
+			assert.Equal(t, tt.wantType, meta.FeedType)
+			assert.Equal(t, tt.wantVersion, meta.FeedVersion)
+		})
+	}
+
+	t.Run("content type fallback", func(t *testing.T) {
+		fetcher := NewDefaultFeedFetcher()
+		ff, err := fetcher.newFeed("https://example.com/feed")
+		require.NoError(t, err)
+		ff.data = &gofeed.Feed{Title: "Example"}
+		ff.contentType = "application/atom+xml"
+
+		meta := fetcher.newFeedMeta(ff)
+		assert.Equal(t, "atom", meta.FeedType)
+		assert.Empty(t, meta.FeedVersion)
+	})
+}
@@ -0,0 +1,45 @@
+package feedfetcher
+
+import "maps"
+
+// MetricsRecorder receives the outcome of every fetch for export to a
+// metrics system: the feed url as given, the statistics of the fetch, the
+// error it returned and its labels, which must not be modified.
+// Implementations must be safe for concurrent use, as FetchMany reports
+// from several goroutines.
+type MetricsRecorder interface {
+	RecordFetch(feedURL string, stats FetchStats, err error, labels map[string]string)
+}
+
+// WithMetricsRecorder returns a new FeedFetcher that reports every fetch to
+// recorder. Pass nil to stop reporting.
+func (f *FeedFetcher) WithMetricsRecorder(recorder MetricsRecorder) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.metrics = recorder
+	return &newFetcher
+}
+
+// WithMetricsLabels returns a new FeedFetcher that passes labels, such as a
+// tenant or feed category, to the metrics recorder with every fetch. They
+// are merged with FetchOptions.MetricsLabels, which win where both set the
+// same label. The map is copied.
+func (f *FeedFetcher) WithMetricsLabels(labels map[string]string) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.metricsLabels = maps.Clone(labels)
+	return &newFetcher
+}
+
+// recordMetrics reports a fetch to the metrics recorder, if there is one.
+func (f *FeedFetcher) recordMetrics(feedURL string, opts FetchOptions, stats FetchStats, err error) {
+	if f.metrics == nil {
+		return
+	}
+
+	labels := f.metricsLabels
+	if len(opts.MetricsLabels) > 0 {
+		labels = make(map[string]string, len(f.metricsLabels)+len(opts.MetricsLabels))
+		maps.Copy(labels, f.metricsLabels)
+		maps.Copy(labels, opts.MetricsLabels)
+	}
+	f.metrics.RecordFetch(feedURL, stats, err, labels)
+}
@@ -0,0 +1,67 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fetchRecord struct {
+	url    string
+	items  int
+	err    error
+	labels map[string]string
+}
+
+type recorder struct {
+	mu      sync.Mutex
+	fetches []fetchRecord
+}
+
+func (r *recorder) RecordFetch(feedURL string, stats FetchStats, err error, labels map[string]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetches = append(r.fetches, fetchRecord{feedURL, stats.ItemsAccepted, err, labels})
+}
+
+func TestFeedFetcher_WithMetricsRecorder(t *testing.T) {
+	now := time.Now()
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			if url == "https://example.com/broken" {
+				return nil, errors.New("boom")
+			}
+			return &gofeed.Feed{Items: []*gofeed.Item{{Title: "One", Link: "/1", PublishedParsed: &now}}}, nil
+		},
+	})
+
+	// Labels without a recorder are simply unused
+	_, err := fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed",
+		FetchOptions{MetricsLabels: map[string]string{"tenant": "acme"}})
+	require.NoError(t, err)
+
+	rec := &recorder{}
+	labels := map[string]string{"tenant": "default", "category": "news"}
+	fetcher = fetcher.WithMetricsRecorder(rec).WithMetricsLabels(labels)
+	labels["tenant"] = "changed later"
+
+	_, err = fetcher.FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	_, err = fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/broken",
+		FetchOptions{MetricsLabels: map[string]string{"tenant": "acme"}})
+	require.Error(t, err)
+
+	require.Len(t, rec.fetches, 2)
+	assert.Equal(t, fetchRecord{"https://example.com/feed", 1, nil,
+		map[string]string{"tenant": "default", "category": "news"}}, rec.fetches[0])
+	assert.Equal(t, "https://example.com/broken", rec.fetches[1].url)
+	assert.Equal(t, err, rec.fetches[1].err)
+	assert.Equal(t, map[string]string{"tenant": "acme", "category": "news"}, rec.fetches[1].labels,
+		"per-fetch labels win")
+}
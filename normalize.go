@@ -0,0 +1,162 @@
+package feedfetcher
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+
+	"github.com/reddot-watch/feedfetcher/internal/domain"
+)
+
+// URLNormalizer rewrites a URL into its canonical form, for example by
+// removing tracking parameters. It receives absolute item links as well as
+// links found in item content, which may be relative, and must return its
+// input unchanged if it cannot handle it.
+type URLNormalizer func(rawURL string) string
+
+// trackingParams are query parameters removed by StripTrackingParams, in
+// addition to any parameter starting with "utm_".
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"dclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+	"_hsenc":  true,
+	"_hsmi":   true,
+}
+
+// StripTrackingParams is a URLNormalizer that removes utm_* and other common
+// click-tracking query parameters. Other parameters keep their order.
+func StripTrackingParams(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.RawQuery == "" {
+		return rawURL
+	}
+
+	params := strings.Split(u.RawQuery, "&")
+	kept := params[:0]
+	for _, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		if decoded, err := url.QueryUnescape(name); err == nil {
+			name = decoded
+		}
+		if strings.HasPrefix(strings.ToLower(name), "utm_") || trackingParams[strings.ToLower(name)] {
+			continue
+		}
+		kept = append(kept, param)
+	}
+
+	u.RawQuery = strings.Join(kept, "&")
+	return u.String()
+}
+
+// WWWPolicy selects how NormalizeWWW treats the "www." prefix of hosts.
+type WWWPolicy int
+
+const (
+	// StripWWW removes a leading "www." from every host, matching how the
+	// rate limiter groups hosts.
+	StripWWW WWWPolicy = iota
+	// AddWWW prefixes "www." to hosts that are a registrable domain, such
+	// as example.com or example.co.uk, and leaves other subdomains alone.
+	AddWWW
+)
+
+// NormalizeWWW returns a URLNormalizer that makes the "www." prefix of hosts
+// consistent according to policy, so that both variants of a site's URLs
+// compare equal. Relative URLs and IP addresses are returned unchanged.
+func NormalizeWWW(policy WWWPolicy) URLNormalizer {
+	return func(rawURL string) string {
+		u, err := url.Parse(rawURL)
+		if err != nil || u.Host == "" || net.ParseIP(u.Hostname()) != nil {
+			return rawURL
+		}
+
+		host, port := u.Hostname(), u.Port()
+		hasWWW := len(host) > 4 && strings.EqualFold(host[:4], "www.")
+		switch {
+		case policy == StripWWW && hasWWW:
+			host = host[4:]
+		case policy == AddWWW && !hasWWW && strings.Contains(host, ".") && domain.Registrable(host) == strings.ToLower(host):
+			host = "www." + host
+		default:
+			return rawURL
+		}
+
+		u.Host = host
+		if port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		}
+		return u.String()
+	}
+}
+
+// ChainNormalizers returns a URLNormalizer that applies normalizers in
+// order, e.g. StripTrackingParams followed by NormalizeWWW(StripWWW).
+func ChainNormalizers(normalizers ...URLNormalizer) URLNormalizer {
+	return func(rawURL string) string {
+		for _, normalize := range normalizers {
+			rawURL = normalize(rawURL)
+		}
+		return rawURL
+	}
+}
+
+// normalizeContentLinks passes the href of every <a> element in content
+// through normalize. Everything else, including markup the tokenizer does not
+// understand, is copied through byte for byte.
+func normalizeContentLinks(content string, normalize URLNormalizer) string {
+	if !strings.Contains(strings.ToLower(content), "<a") {
+		return content
+	}
+
+	var out bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				// Should not happen for an in-memory reader; keep the original
+				return content
+			}
+			return out.String()
+		}
+
+		raw := z.Raw()
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			out.Write(raw)
+			continue
+		}
+
+		// Raw is only valid until the next call that reads the token
+		original := string(raw)
+		token := z.Token()
+		if token.Data != "a" || !rewriteHref(&token, normalize) {
+			out.WriteString(original)
+			continue
+		}
+		out.WriteString(token.String())
+	}
+}
+
+// rewriteHref normalizes the href attribute of token, reporting whether it
+// changed.
+func rewriteHref(token *html.Token, normalize URLNormalizer) bool {
+	for i, attr := range token.Attr {
+		if attr.Namespace != "" || attr.Key != "href" {
+			continue
+		}
+		if normalized := normalize(attr.Val); normalized != attr.Val {
+			token.Attr[i].Val = normalized
+			return true
+		}
+		return false
+	}
+	return false
+}
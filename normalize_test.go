@@ -0,0 +1,98 @@
+package feedfetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStripTrackingParams(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"https://example.com/a", "https://example.com/a"},
+		{"https://example.com/a?utm_source=rss&utm_medium=feed", "https://example.com/a"},
+		{"https://example.com/a?id=7&UTM_Campaign=x&fbclid=abc&page=2", "https://example.com/a?id=7&page=2"},
+		{"https://example.com/a?utm_source=rss#comments", "https://example.com/a#comments"},
+		{"/relative?utm_source=rss&q=go", "/relative?q=go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, StripTrackingParams(tt.input))
+		})
+	}
+}
+
+func TestNormalizeWWW(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy WWWPolicy
+		in     string
+		want   string
+	}{
+		{"strip", StripWWW, "https://www.example.com/a?b=1#c", "https://example.com/a?b=1#c"},
+		{"strip keeps port", StripWWW, "http://WWW.example.com:8080/a", "http://example.com:8080/a"},
+		{"strip without www", StripWWW, "https://news.example.com/a", "https://news.example.com/a"},
+		{"strip only a prefix", StripWWW, "https://www2.example.com/a", "https://www2.example.com/a"},
+		{"add", AddWWW, "https://example.com/a", "https://www.example.com/a"},
+		{"add under public suffix", AddWWW, "https://example.co.uk/a", "https://www.example.co.uk/a"},
+		{"add leaves subdomains", AddWWW, "https://news.example.com/a", "https://news.example.com/a"},
+		{"add keeps www", AddWWW, "https://www.example.com/a", "https://www.example.com/a"},
+		{"add skips localhost", AddWWW, "http://localhost/a", "http://localhost/a"},
+		{"ip address", AddWWW, "http://192.0.2.1/a", "http://192.0.2.1/a"},
+		{"relative", StripWWW, "/a", "/a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeWWW(tt.policy)(tt.in))
+		})
+	}
+}
+
+func TestChainNormalizers(t *testing.T) {
+	normalize := ChainNormalizers(StripTrackingParams, NormalizeWWW(StripWWW))
+	assert.Equal(t, "https://example.com/a?id=1", normalize("https://www.example.com/a?utm_source=x&id=1"))
+}
+
+func TestNormalizeContentLinks(t *testing.T) {
+	content := `<p>Read <a href="https://example.com/a?utm_source=rss&amp;id=1" class="x">this</a>` +
+		` and <A HREF='/b?fbclid=1'>that</A>.</p><img src="https://example.com/i.png?utm_source=rss"><br/>` +
+		`<a href="https://example.com/clean">clean</a>`
+
+	want := `<p>Read <a href="https://example.com/a?id=1" class="x">this</a>` +
+		` and <a href="/b">that</A>.</p><img src="https://example.com/i.png?utm_source=rss"><br/>` +
+		`<a href="https://example.com/clean">clean</a>`
+
+	assert.Equal(t, want, normalizeContentLinks(content, StripTrackingParams))
+	assert.Equal(t, "plain text", normalizeContentLinks("plain text", StripTrackingParams))
+}
+
+func TestValidateAndConvertItem_URLNormalizer(t *testing.T) {
+	feedURL := mustParseURL(t, "https://example.com/feed")
+	item := &gofeed.Item{
+		Title:           "Story",
+		Link:            "/a?utm_source=rss",
+		Description:     `<a href="https://example.com/b?utm_medium=feed">more</a>`,
+		PublishedParsed: timePtr(time.Now()),
+	}
+
+	config := DefaultConfig
+	config.URLNormalizer = StripTrackingParams
+
+	got, err := validateAndConvertItem(config, feedURL, item)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/a", got.URL)
+	assert.Equal(t, "https://example.com/a", got.Links[0].Href)
+	assert.Equal(t, item.Description, got.Content, "content is only rewritten on request")
+
+	config.NormalizeContentLinks = true
+	got, err = validateAndConvertItem(config, feedURL, item)
+	require.NoError(t, err)
+	assert.Equal(t, `<a href="https://example.com/b">more</a>`, got.Content)
+}
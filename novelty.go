@@ -0,0 +1,38 @@
+package feedfetcher
+
+import "github.com/reddot-watch/feedfetcher/internal/novelty"
+
+// WithEmptyFetchTracking returns a new FeedFetcher that counts, per feed url,
+// the successful fetches in a row that brought no item missing from the
+// previous fetch, for polling schedulers that back off quiet feeds. Items are
+// compared by URL. At most maxFeeds feeds are tracked; beyond that the feed
+// fetched least recently is forgotten and starts over. Use maxFeeds <= 0 to
+// disable tracking. Failed fetches leave the count alone.
+//
+// The new fetcher starts with empty counts; copies made from it with other
+// With methods share them.
+func (f *FeedFetcher) WithEmptyFetchTracking(maxFeeds int) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.novelty = nil
+	if maxFeeds > 0 {
+		newFetcher.novelty = novelty.New(maxFeeds)
+	}
+	return &newFetcher
+}
+
+// ConsecutiveEmptyFetches returns how many successful fetches of feedURL in a
+// row found no new items. It is 0 after a fetch with new items, for feeds
+// fetched only once, and when tracking is off; see WithEmptyFetchTracking.
+func (f *FeedFetcher) ConsecutiveEmptyFetches(feedURL string) int {
+	if f.novelty == nil {
+		return 0
+	}
+	n, _ := f.novelty.ConsecutiveEmpty(feedURL)
+	return n
+}
+
+// observeNovelty records the items of a successful fetch with the empty
+// fetch tracker and counts the new ones in the fetch's stats.
+func (f *FeedFetcher) observeNovelty(feed *feed) {
+	feed.stats.NewItems, _ = f.novelty.Observe(feed.url, feed.seenKeys)
+}
@@ -0,0 +1,51 @@
+package feedfetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedFetcher_WithEmptyFetchTracking(t *testing.T) {
+	now := time.Now()
+	var links []string
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			feed := &gofeed.Feed{}
+			for _, link := range links {
+				feed.Items = append(feed.Items, &gofeed.Item{Title: link, Link: link, PublishedParsed: &now})
+			}
+			return feed, nil
+		},
+	})
+	const feedURL = "https://example.com/feed"
+
+	fetch := func(f *FeedFetcher, items ...string) FetchStats {
+		t.Helper()
+		links = items
+		_, stats, err := f.FetchAndProcessWithStats(context.Background(), feedURL)
+		require.NoError(t, err)
+		return stats
+	}
+
+	fetch(fetcher, "/a")
+	fetch(fetcher, "/a")
+	assert.Zero(t, fetcher.ConsecutiveEmptyFetches(feedURL), "off by default")
+
+	tracking := fetcher.WithEmptyFetchTracking(100)
+	assert.Equal(t, 2, fetch(tracking, "/a", "/b").NewItems, "first fetch")
+	assert.Zero(t, tracking.ConsecutiveEmptyFetches(feedURL))
+
+	assert.Zero(t, fetch(tracking, "/b", "/a").NewItems)
+	fetch(tracking)
+	assert.Equal(t, 2, tracking.ConsecutiveEmptyFetches(feedURL))
+
+	assert.Equal(t, 1, fetch(tracking, "/c").NewItems)
+	assert.Zero(t, tracking.ConsecutiveEmptyFetches(feedURL))
+
+	assert.Zero(t, tracking.WithEmptyFetchTracking(0).ConsecutiveEmptyFetches(feedURL), "disabled")
+}
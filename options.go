@@ -0,0 +1,67 @@
+package feedfetcher
+
+import (
+	"net/http"
+	"time"
+)
+
+// FetchOptions holds overrides applied to a single FetchAndProcessWithOptions
+// call without modifying the fetcher. Zero-valued fields fall back to the
+// fetcher's configuration, so use a negative MaxItems or MaxAge to disable
+// the item or age limit for one call. FutureDriftTolerance is a pointer
+// because zero is a meaningful tolerance: nil keeps the fetcher's setting.
+type FetchOptions struct {
+	UserAgent string
+	Headers   http.Header
+	Timeout   time.Duration
+
+	MaxItems             int
+	MaxAge               time.Duration
+	MaxHeadingLength     int
+	FutureDriftTolerance *time.Duration
+
+	// OnItems, if set, receives the accepted items in batches of up to
+	// ItemBufferSize (100 if zero) while the feed is being converted,
+	// instead of the call returning them all at once, so a huge feed never
+	// has all its items converted in memory at the same time. The returned
+	// slice is then empty. Batches are delivered in feed order from the
+	// calling goroutine, and OnItems may keep them. A fetch can still fail
+	// after some batches were delivered, e.g. on an unparseable date or
+	// with StrictValidation. DuplicateGUID is only set on an item whose
+	// duplicate is in the same or an earlier batch.
+	OnItems        func(items []*FeedItem)
+	ItemBufferSize int
+
+	// MetricsLabels are passed to the fetcher's MetricsRecorder for this
+	// fetch, on top of those set with WithMetricsLabels.
+	MetricsLabels map[string]string
+}
+
+// defaultItemBufferSize is the batch size of FetchOptions.OnItems when
+// ItemBufferSize is not set.
+const defaultItemBufferSize = 100
+
+// configFor returns the fetcher's configuration with the per-call overrides
+// from opts applied.
+func (f *FeedFetcher) configFor(opts FetchOptions) Config {
+	config := f.config
+	if opts.UserAgent != "" {
+		config.UserAgent = opts.UserAgent
+	}
+	if opts.Timeout > 0 {
+		config.RequestTimeout = opts.Timeout
+	}
+	if opts.MaxItems != 0 {
+		config.MaxItems = opts.MaxItems
+	}
+	if opts.MaxAge != 0 {
+		config.MaxAge = opts.MaxAge
+	}
+	if opts.MaxHeadingLength != 0 {
+		config.MaxHeadingLength = opts.MaxHeadingLength
+	}
+	if opts.FutureDriftTolerance != nil {
+		config.FutureDriftTolerance = *opts.FutureDriftTolerance
+	}
+	return config
+}
@@ -0,0 +1,69 @@
+package feedfetcher
+
+import (
+	"net/url"
+
+	"github.com/mmcdole/gofeed"
+	ext "github.com/mmcdole/gofeed/extensions"
+)
+
+// podcastPrefix is the prefix the Podcasting 2.0 namespace
+// (https://podcastindex.org/namespace/1.0) is declared with. gofeed keys
+// extensions by the prefix used in the document, and feeds use this one.
+const podcastPrefix = "podcast"
+
+// Podcast holds the Podcasting 2.0 data of an episode. URLs are resolved
+// against the feed url.
+type Podcast struct {
+	Transcripts  []Transcript
+	ChaptersURL  string // JSON chapters file, if any
+	ChaptersType string // Usually application/json+chapters
+}
+
+// Transcript is a podcast:transcript of an episode.
+type Transcript struct {
+	URL      string
+	Type     string // e.g. text/vtt, application/srt or text/html
+	Language string // Empty if the feed does not say
+	Rel      string // "captions" if the transcript is usable as closed captions
+}
+
+// itemPodcast extracts the Podcasting 2.0 data of item, or returns nil if it
+// has none.
+func itemPodcast(feedURL, base *url.URL, item *gofeed.Item) *Podcast {
+	extensions := item.Extensions[podcastPrefix]
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	podcast := &Podcast{}
+	for _, e := range extensions["transcript"] {
+		if href := resolveOptionalURL(feedURL, base, e.Attrs["url"]); href != "" {
+			podcast.Transcripts = append(podcast.Transcripts, Transcript{
+				URL:      href,
+				Type:     e.Attrs["type"],
+				Language: e.Attrs["language"],
+				Rel:      e.Attrs["rel"],
+			})
+		}
+	}
+	if chapters := firstWithURL(feedURL, base, extensions["chapters"]); chapters != nil {
+		podcast.ChaptersURL = resolveOptionalURL(feedURL, base, chapters.Attrs["url"])
+		podcast.ChaptersType = chapters.Attrs["type"]
+	}
+
+	if len(podcast.Transcripts) == 0 && podcast.ChaptersURL == "" {
+		return nil
+	}
+	return podcast
+}
+
+// firstWithURL returns the first extension with a valid url attribute.
+func firstWithURL(feedURL, base *url.URL, extensions []ext.Extension) *ext.Extension {
+	for i := range extensions {
+		if resolveOptionalURL(feedURL, base, extensions[i].Attrs["url"]) != "" {
+			return &extensions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package feedfetcher
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const podcastFeed = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+<channel>
+<title>Show</title>
+<item>
+  <title>Episode 1</title>
+  <link>https://example.com/ep1</link>
+  <podcast:transcript url="/ep1.vtt" type="text/vtt" language="en" rel="captions"/>
+  <podcast:transcript url="https://cdn.example.com/ep1.html" type="text/html"/>
+  <podcast:transcript type="text/plain"/>
+  <podcast:chapters url="ep1/chapters.json" type="application/json+chapters"/>
+</item>
+<item>
+  <title>Episode 2</title>
+  <link>https://example.com/ep2</link>
+</item>
+</channel>
+</rss>`
+
+func TestItemPodcast(t *testing.T) {
+	feed, err := gofeed.NewParser().ParseString(podcastFeed)
+	require.NoError(t, err)
+	require.Len(t, feed.Items, 2)
+	base := mustParseURL(t, "https://example.com/shows/feed.xml")
+
+	got := itemPodcast(base, base, feed.Items[0])
+	require.NotNil(t, got)
+	assert.Equal(t, []Transcript{
+		{URL: "https://example.com/ep1.vtt", Type: "text/vtt", Language: "en", Rel: "captions"},
+		{URL: "https://cdn.example.com/ep1.html", Type: "text/html"},
+	}, got.Transcripts, "transcripts without a url are skipped")
+	assert.Equal(t, "https://example.com/shows/ep1/chapters.json", got.ChaptersURL)
+	assert.Equal(t, "application/json+chapters", got.ChaptersType)
+
+	assert.Nil(t, itemPodcast(base, base, feed.Items[1]))
+	assert.Nil(t, itemPodcast(base, base, &gofeed.Item{}))
+}
+
+func TestConvertItems_Podcast(t *testing.T) {
+	feed, err := gofeed.NewParser().ParseString(podcastFeed)
+	require.NoError(t, err)
+	now := time.Now()
+	for _, item := range feed.Items {
+		item.PublishedParsed = &now
+	}
+
+	got, itemErrs := ConvertItems(mustParseURL(t, "https://example.com/feed"), feed.Items, DefaultConfig)
+	require.Empty(t, itemErrs)
+	require.Len(t, got, 2)
+	require.NotNil(t, got[0].Podcast)
+	assert.Len(t, got[0].Podcast.Transcripts, 2)
+	assert.Nil(t, got[1].Podcast)
+}
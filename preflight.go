@@ -0,0 +1,86 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/reddot-watch/feedfetcher/internal/validation"
+)
+
+// FeedURLValidation is the outcome of checking one feed url with
+// ValidateFeedURLs.
+type FeedURLValidation struct {
+	URL       string
+	Reachable bool   // The server answered with a document
+	IsFeed    bool   // The document parsed as an RSS, Atom or JSON feed
+	FeedType  string // "rss", "atom" or "json" when IsFeed is set
+	Error     error  // The first problem found; nil if the feed fetched cleanly
+}
+
+// ValidateFeedURLs checks a list of feed urls before they are put to use,
+// e.g. as a pre-flight step in CI: each must be an absolute http or https url
+// and fetch as a feed the way FetchAndProcess would. A feed that parses but
+// fails item validation is reachable and a feed, with Error set. URLs are
+// checked concurrently; the results are in the order given.
+//
+// Checking a feed leaves no trace on the fetcher beyond its rate limits:
+// hooks, metrics, the circuit breaker, latency and empty fetch tracking and
+// the dedup cache all ignore it, so a validated feed fetches as if new.
+func (f *FeedFetcher) ValidateFeedURLs(ctx context.Context, feedURLs []string) []FeedURLValidation {
+	results := make([]FeedURLValidation, len(feedURLs))
+	forEachConcurrently(len(feedURLs), defaultBatchConcurrency, func(i int) {
+		results[i] = f.validateFeedURL(ctx, feedURLs[i])
+	})
+	return results
+}
+
+func (f *FeedFetcher) validateFeedURL(ctx context.Context, feedURL string) FeedURLValidation {
+	result := FeedURLValidation{URL: feedURL}
+	if !validation.IsAbsoluteHTTPURL(feedURL) {
+		result.Error = newFeedError(feedURL, PhaseValidate,
+			fmt.Errorf("%w: not an absolute http or https url", ErrInvalidURL))
+		return result
+	}
+
+	ff, err := f.probe(ctx, feedURL)
+	result.Error = err
+	if ff != nil && ff.data != nil {
+		result.Reachable = true
+		result.IsFeed = true
+		result.FeedType = ff.data.FeedType
+		return result
+	}
+
+	var feedErr *FeedError
+	if errors.As(err, &feedErr) && feedErr.Phase == PhaseParse {
+		result.Reachable = true // downloaded, but not a feed
+	}
+	return result
+}
+
+// probe downloads and parses feedURL and checks its items as fetch would,
+// but only reports the outcome; see ValidateFeedURLs. It runs on a copy of
+// the fetcher without the hooks that would otherwise see the feed, and skips
+// the steps of fetch that record it.
+func (f *FeedFetcher) probe(ctx context.Context, feedURL string) (*feed, error) {
+	quiet := *f
+	quiet.responseHook = nil
+	quiet.dateSink = nil
+
+	config := quiet.configFor(FetchOptions{})
+	if err := config.Validate(); err != nil {
+		return nil, newFeedError(feedURL, PhaseValidate, err)
+	}
+
+	ff, err := quiet.prepareFeed(feedURL, FetchOptions{})
+	if err != nil {
+		return ff, err
+	}
+	if err := quiet.downloadWithRetries(ctx, ff, config); err != nil {
+		return ff, err
+	}
+
+	_, err = quiet.convertFeed(ff, quiet.itemConfig(ff))
+	return ff, err
+}
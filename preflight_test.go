@@ -0,0 +1,128 @@
+package feedfetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestFeedFetcher_ValidateFeedURLs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rss":
+			fmt.Fprint(w, rssDocument(time.Now(), "First"))
+		case "/atom":
+			fmt.Fprintf(w, `<feed xmlns="http://www.w3.org/2005/Atom"><title>A</title>
+				<entry><title>E</title><link href="https://example.com/e"/><updated>%s</updated></entry></feed>`,
+				time.Now().UTC().Format(time.RFC3339))
+		case "/bad-dates":
+			fmt.Fprint(w, `<rss version="2.0"><channel><title>T</title>
+				<item><title>I</title><link>https://example.com/i</link><pubDate>someday</pubDate></item></channel></rss>`)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, errorPage)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	urls := []string{
+		server.URL + "/rss",
+		server.URL + "/atom",
+		server.URL + "/bad-dates",
+		server.URL + "/page",
+		server.URL + "/missing",
+		"not a url",
+		"ftp://example.com/feed",
+	}
+	fetcher := NewDefaultFeedFetcher()
+	fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+	results := fetcher.ValidateFeedURLs(context.Background(), urls)
+	require.Len(t, results, len(urls))
+
+	type summary struct {
+		Reachable, IsFeed bool
+		FeedType          string
+		HasErr            bool
+	}
+	want := []summary{
+		{true, true, "rss", false},
+		{true, true, "atom", false},
+		{true, true, "rss", true},
+		{true, false, "", true},
+		{false, false, "", true},
+		{false, false, "", true},
+		{false, false, "", true},
+	}
+	for i, result := range results {
+		assert.Equal(t, urls[i], result.URL)
+		assert.Equal(t, want[i], summary{result.Reachable, result.IsFeed, result.FeedType, result.Error != nil}, urls[i])
+	}
+	assert.ErrorIs(t, results[2].Error, ErrFeedPublicationDateFormat)
+	assert.ErrorIs(t, results[5].Error, ErrInvalidURL)
+}
+
+func TestFeedFetcher_ValidateFeedURLs_NoSideEffects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "First", "Second"))
+	}))
+	defer server.Close()
+
+	var completed, responses int
+	rec := &recorder{}
+	fetcher := NewDefaultFeedFetcher().
+		WithDedupCache(NewDedupCache(time.Hour, 100)).
+		WithEmptyFetchTracking(10).
+		WithOnFetchComplete(func(string, FetchStats, error) { completed++ }).
+		WithResponseHook(func(string, []byte, http.Header) { responses++ }).
+		WithMetricsRecorder(rec)
+	fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+	results := fetcher.ValidateFeedURLs(context.Background(), []string{server.URL})
+	require.NoError(t, results[0].Error)
+	assert.Zero(t, completed)
+	assert.Zero(t, responses)
+	assert.Empty(t, rec.fetches)
+
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 2, "validation marked nothing as seen")
+	assert.Equal(t, 1, completed)
+	assert.Equal(t, 1, responses)
+	assert.Len(t, rec.fetches, 1)
+}
+
+func TestFeedFetcher_ValidateFeedURLs_MatchesFetch(t *testing.T) {
+	body := `<?xml version="1.0"?><rss version="2.0"><channel><title>Dates</title>` +
+		`<item><title>One</title><link>https://example.com/1</link><pubDate>someday soon</pubDate></item>` +
+		`</channel></rss>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	dates := make(chan string, 2)
+	fetcher := NewDefaultFeedFetcher().
+		WithUnparseableDateSink(func(feedURL, raw string) { dates <- raw })
+	fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+	results := fetcher.ValidateFeedURLs(context.Background(), []string{server.URL})
+	_, fetchErr := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.ErrorIs(t, fetchErr, ErrFeedPublicationDateFormat)
+	assert.Equal(t, fetchErr.Error(), results[0].Error.Error(), "validation fails the way fetching does")
+
+	assert.Equal(t, "someday soon", <-dates)
+	select {
+	case raw := <-dates:
+		t.Errorf("validation reported %q to the date sink", raw)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
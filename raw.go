@@ -0,0 +1,58 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+)
+
+// errRawUnsupported is returned by FetchRaw when the fetcher's parser can
+// only download and parse in one step.
+var errRawUnsupported = errors.New("parser cannot download without parsing")
+
+// FetchRaw downloads a feed and returns the body and headers exactly as the
+// server sent them, without parsing, e.g. to archive responses or reprocess
+// them later with ParseReader. The download waits for the rate limiter and
+// uses the same HTTP transport, timeout, user agent, request headers and
+// domain access lists as FetchAndProcess, and fails the same way for non-2xx
+// responses and bodies over Config.MaxBodySize. It is not retried.
+func (f *FeedFetcher) FetchRaw(ctx context.Context, feedURL string) ([]byte, http.Header, error) {
+	config := f.configFor(FetchOptions{})
+	if err := config.Validate(); err != nil {
+		return nil, nil, newFeedError(feedURL, PhaseValidate, err)
+	}
+
+	ff, err := f.prepareFeed(feedURL, FetchOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fp, ok := f.parser.(feedparser.Fetcher)
+	if !ok {
+		return nil, nil, newFeedError(feedURL, PhaseDownload, errRawUnsupported)
+	}
+
+	if err := f.rateLimiter.WaitForDomainWithin(ctx, feedURL, config.MaxRateLimitWait); err != nil {
+		return nil, nil, newFeedError(feedURL, PhaseDownload, err)
+	}
+
+	fetchCtx, cancel := context.WithTimeout(ctx, config.RequestTimeout)
+	defer cancel()
+
+	body, header, err := fp.Fetch(fetchCtx, feedparser.Request{
+		URL:         feedURL,
+		UserAgent:   ff.opts.UserAgent,
+		Header:      ff.opts.Headers,
+		MaxBodySize: config.MaxBodySize,
+	})
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %v: %w", config.RequestTimeout, err)
+	}
+	if err != nil {
+		return nil, nil, newFeedError(feedURL, PhaseDownload, err)
+	}
+	return body, header, nil
+}
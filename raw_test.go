@@ -0,0 +1,97 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+func TestFeedFetcher_FetchRaw(t *testing.T) {
+	body := "<rss version=\"2.0\"><channel><title>Not parsed</title></channel>  \n"
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Path == "/slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		userAgent = r.UserAgent()
+		w.Header().Set("Content-Type", "application/rss+xml")
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	fetcher := NewDefaultFeedFetcher().WithUserAgent("raw-test/1.0")
+	fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+	got, header, err := fetcher.FetchRaw(context.Background(), server.URL+"/feed")
+	require.NoError(t, err)
+	assert.Equal(t, body, string(got), "body is returned byte for byte")
+	assert.Equal(t, `"v1"`, header.Get("ETag"))
+	assert.Equal(t, "raw-test/1.0", userAgent)
+
+	_, _, err = fetcher.FetchRaw(context.Background(), server.URL+"/missing")
+	var feedErr *FeedError
+	require.True(t, errors.As(err, &feedErr))
+	assert.Equal(t, PhaseDownload, feedErr.Phase)
+
+	_, _, err = fetcher.WithRequestTimeout(50*time.Millisecond).FetchRaw(context.Background(), server.URL+"/slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, _, err = fetcher.WithBlockedDomains([]string{"127.0.0.1"}).FetchRaw(context.Background(), server.URL+"/feed")
+	assert.ErrorIs(t, err, ErrBlockedDomain)
+}
+
+func TestFeedFetcher_MaxBodySize(t *testing.T) {
+	doc := rssDocument(time.Now(), "Sized")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunked" {
+			// Flushing first leaves Content-Length out of the response
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte(doc))
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/sized", "/chunked"} {
+		t.Run(path, func(t *testing.T) {
+			config := DefaultConfig
+			config.MaxBodySize = int64(len(doc))
+			fetcher := NewFeedFetcher(config)
+			fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+			items, err := fetcher.FetchAndProcess(context.Background(), server.URL+path)
+			require.NoError(t, err, "a body at the limit is accepted")
+			assert.Len(t, items, 1)
+			body, _, err := fetcher.FetchRaw(context.Background(), server.URL+path)
+			require.NoError(t, err)
+			assert.Equal(t, doc, string(body))
+
+			config.MaxBodySize--
+			fetcher = NewFeedFetcher(config)
+			fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+			_, err = fetcher.FetchAndProcess(context.Background(), server.URL+path)
+			assert.ErrorIs(t, err, ErrBodyTooLarge)
+			var feedErr *FeedError
+			require.ErrorAs(t, err, &feedErr)
+			assert.Equal(t, PhaseDownload, feedErr.Phase)
+			_, _, err = fetcher.FetchRaw(context.Background(), server.URL+path)
+			assert.ErrorIs(t, err, ErrBodyTooLarge)
+		})
+	}
+
+	config := DefaultConfig
+	config.MaxBodySize = -1
+	assert.ErrorIs(t, config.Validate(), ErrInvalidConfig)
+}
@@ -0,0 +1,92 @@
+package feedfetcher
+
+import (
+	"container/list"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// PermanentRedirectError reports that a feed url answered with a permanent
+// redirect (301 or 308) while Config.ReportPermanentRedirect is set. It
+// matches ErrPermanentlyMoved with errors.Is; use errors.As to get Location
+// and update the stored feed url.
+type PermanentRedirectError struct {
+	FeedURL  string
+	Location string // Absolute url the feed moved to
+}
+
+func (e *PermanentRedirectError) Error() string {
+	return fmt.Sprintf("%v: %s moved to %s", ErrPermanentlyMoved, e.FeedURL, e.Location)
+}
+
+// Unwrap returns ErrPermanentlyMoved.
+func (e *PermanentRedirectError) Unwrap() error {
+	return ErrPermanentlyMoved
+}
+
+// isPermanentRedirect reports whether resp is a 301 or 308 response.
+func isPermanentRedirect(resp *http.Response) bool {
+	return resp != nil &&
+		(resp.StatusCode == http.StatusMovedPermanently || resp.StatusCode == http.StatusPermanentRedirect)
+}
+
+// maxMovedFeeds bounds the permanent redirects a fetcher remembers.
+const maxMovedFeeds = 1024
+
+// movedFeeds remembers the permanent redirects reported to the caller, so a
+// feed that is moved back to a url it was moved away from, e.g. A to B on
+// one poll and B to A on the next, is recognized as a loop. Only the most
+// recently reported moves are kept, up to max; a loop through a forgotten
+// move is then caught on its next round instead.
+type movedFeeds struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]*list.Element // of *movedFeed, keyed by from
+	lru     *list.List               // most recently reported first
+}
+
+type movedFeed struct {
+	from, to string
+}
+
+func newMovedFeeds() *movedFeeds {
+	return &movedFeeds{max: maxMovedFeeds, entries: make(map[string]*list.Element), lru: list.New()}
+}
+
+// move records that from moved to to. It returns false without recording
+// anything when following the moves recorded so far from to leads back to
+// from.
+func (m *movedFeeds) move(from, to string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := map[string]bool{}
+	for next := to; next != "" && !seen[next]; next = m.target(next) {
+		if next == from {
+			return false
+		}
+		seen[next] = true
+	}
+
+	if el, ok := m.entries[from]; ok {
+		el.Value.(*movedFeed).to = to
+		m.lru.MoveToFront(el)
+		return true
+	}
+	m.entries[from] = m.lru.PushFront(&movedFeed{from: from, to: to})
+	if m.lru.Len() > m.max {
+		oldest := m.lru.Back()
+		m.lru.Remove(oldest)
+		delete(m.entries, oldest.Value.(*movedFeed).from)
+	}
+	return true
+}
+
+// target returns where from was recorded to have moved, or "".
+func (m *movedFeeds) target(from string) string {
+	if el, ok := m.entries[from]; ok {
+		return el.Value.(*movedFeed).to
+	}
+	return ""
+}
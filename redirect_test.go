@@ -0,0 +1,112 @@
+package feedfetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// redirectServer serves the feed at /feed and answers the paths in routes
+// with a redirect of the given status to the mapped path.
+func redirectServer(t *testing.T, routes map[string]string, status int) *httptest.Server {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if to, ok := routes[r.URL.Path]; ok {
+			http.Redirect(w, r, server.URL+to, status)
+			return
+		}
+		fmt.Fprint(w, rssDocument(time.Now(), "Moved"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFeedFetcher_PermanentRedirect(t *testing.T) {
+	server := redirectServer(t, map[string]string{"/old": "/older", "/older": "/feed"}, http.StatusMovedPermanently)
+
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, nil)
+	fetcher.parser = fetcher.newParser()
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL+"/old")
+	require.NoError(t, err, "followed by default")
+	assert.Len(t, items, 1)
+
+	config := DefaultConfig
+	config.ReportPermanentRedirect = true
+	fetcher = NewFeedFetcherWithParser(config, nil)
+	fetcher.parser = fetcher.newParser()
+
+	_, err = fetcher.FetchAndProcess(context.Background(), server.URL+"/old")
+	require.ErrorIs(t, err, ErrPermanentlyMoved)
+	var moved *PermanentRedirectError
+	require.True(t, errors.As(err, &moved))
+	assert.Equal(t, server.URL+"/old", moved.FeedURL)
+	assert.Equal(t, server.URL+"/older", moved.Location, "reports the first hop")
+
+	_, err = fetcher.FetchAndProcess(context.Background(), server.URL+"/feed")
+	assert.NoError(t, err)
+
+	// Temporary redirects are still followed
+	found := redirectServer(t, map[string]string{"/old": "/feed"}, http.StatusFound)
+	_, err = fetcher.FetchAndProcess(context.Background(), found.URL+"/old")
+	assert.NoError(t, err)
+}
+
+func TestFeedFetcher_RedirectLoop(t *testing.T) {
+	t.Run("within one fetch", func(t *testing.T) {
+		server := redirectServer(t, map[string]string{"/a": "/b", "/b": "/c", "/c": "/a"}, http.StatusFound)
+		fetcher := NewFeedFetcherWithParser(DefaultConfig, nil)
+		fetcher.parser = fetcher.newParser()
+
+		_, err := fetcher.FetchAndProcess(context.Background(), server.URL+"/a")
+		assert.ErrorIs(t, err, ErrRedirectLoop)
+		assert.NotErrorIs(t, err, ErrTooManyRedirects)
+	})
+
+	t.Run("across fetches", func(t *testing.T) {
+		server := redirectServer(t, map[string]string{"/a": "/b", "/b": "/a"}, http.StatusMovedPermanently)
+		config := DefaultConfig
+		config.ReportPermanentRedirect = true
+		fetcher := NewFeedFetcherWithParser(config, nil)
+		fetcher.parser = fetcher.newParser()
+
+		_, err := fetcher.FetchAndProcess(context.Background(), server.URL+"/a")
+		var moved *PermanentRedirectError
+		require.True(t, errors.As(err, &moved))
+		assert.Equal(t, server.URL+"/b", moved.Location)
+
+		// The caller follows the move, and the feed moves straight back
+		_, err = fetcher.FetchAndProcess(context.Background(), moved.Location)
+		assert.ErrorIs(t, err, ErrRedirectLoop)
+		assert.NotErrorIs(t, err, ErrPermanentlyMoved)
+	})
+}
+
+func TestMovedFeeds(t *testing.T) {
+	moves := newMovedFeeds()
+	assert.True(t, moves.move("a", "b"))
+	assert.True(t, moves.move("b", "c"))
+	assert.False(t, moves.move("c", "a"), "c -> a -> b -> c")
+	assert.True(t, moves.move("c", "d"))
+	assert.True(t, moves.move("a", "b"), "repeating a move is not a loop")
+}
+
+func TestMovedFeeds_Bounded(t *testing.T) {
+	moves := newMovedFeeds()
+	moves.max = 2
+	assert.True(t, moves.move("a", "b"))
+	assert.True(t, moves.move("x", "y"))
+	assert.True(t, moves.move("a", "b"), "refreshes a -> b")
+	assert.True(t, moves.move("p", "q"))
+	assert.Equal(t, 2, moves.lru.Len())
+	assert.Len(t, moves.entries, 2)
+	assert.Empty(t, moves.target("x"), "least recently reported move is evicted")
+	assert.False(t, moves.move("b", "a"), "a -> b is still known")
+}
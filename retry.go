@@ -0,0 +1,233 @@
+package feedfetcher
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+)
+
+// defaultRetryBackoff is the delay before the first retry when
+// Config.RetryBackoff is zero.
+const defaultRetryBackoff = time.Second
+
+// maxRetryAfter is the longest Retry-After a fetch waits for; a server
+// asking for a longer pause is not retried.
+const maxRetryAfter = 5 * time.Minute
+
+// downloadWithRetries downloads a feed, retrying transient failures up to
+// config.MaxRetries times. Every attempt waits for the rate limiter; running
+// into MaxRateLimitWait is never retried, and on a retry it returns the
+// failure being retried instead. A 429 or 503 response with a Retry-After
+// header is retried no sooner than it asks for. With
+// config.MaxTotalFetchTime set, attempts and the waits between them are
+// bounded together, and running out of time returns the last attempt's
+// error rather than the timeout. Cancelling ctx interrupts a backoff wait
+// immediately.
+func (f *FeedFetcher) downloadWithRetries(ctx context.Context, feed *feed, config Config) error {
+	delay := config.RetryBackoff
+	if delay <= 0 {
+		delay = defaultRetryBackoff
+	}
+
+	budgetCtx := ctx
+	if config.MaxTotalFetchTime > 0 {
+		var cancel context.CancelFunc
+		budgetCtx, cancel = context.WithTimeout(ctx, config.MaxTotalFetchTime)
+		defer cancel()
+	}
+	deadline, hasDeadline := budgetCtx.Deadline()
+
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		err := f.rateLimiter.WaitForDomainWithin(budgetCtx, feed.url, config.MaxRateLimitWait)
+		if err != nil {
+			if errors.Is(err, ErrRateLimited) && lastErr != nil {
+				return lastErr
+			}
+			err = newFeedError(feed.url, PhaseDownload, err)
+		} else {
+			err = f.download(budgetCtx, feed)
+		}
+
+		if err != nil && ctx.Err() == nil && budgetCtx.Err() != nil && lastErr != nil {
+			return lastErr // out of budget; the earlier failure is the informative one
+		}
+		if err == nil || attempt >= config.MaxRetries || budgetCtx.Err() != nil || !f.isRetryable(config, err) {
+			return err
+		}
+		lastErr = err
+
+		wait := delay
+		if after, ok := retryAfter(err, time.Now()); ok {
+			if after > maxRetryAfter {
+				return lastErr
+			}
+			wait = max(wait, after)
+		}
+		if hasDeadline && time.Until(deadline) < wait {
+			return lastErr
+		}
+
+		f.logger.Warn().
+			Str("url", feed.url).
+			Int("attempt", attempt+1).
+			Dur("backoff", wait).
+			Err(err).
+			Msg("fetch failed, retrying")
+
+		if err := sleep(ctx, wait); err != nil {
+			return newFeedError(feed.url, PhaseDownload, err)
+		}
+		delay *= 2
+	}
+}
+
+// isRetryable reports whether a failed download may succeed if repeated.
+// Failures caused by the fetcher's own policies never are, and of the other
+// download failures only network errors and timeouts are.
+func (f *FeedFetcher) isRetryable(config Config, err error) bool {
+	var httpErr gofeed.HTTPError
+	switch {
+	case errors.Is(err, ErrSoftError):
+		return config.RetrySoftErrors
+	case errors.As(err, &httpErr):
+		if f.retryableStatus != nil {
+			return f.retryableStatus[httpErr.StatusCode]
+		}
+		return httpErr.StatusCode >= 500 || httpErr.StatusCode == http.StatusTooManyRequests
+	case errors.Is(err, ErrRateLimited),
+		errors.Is(err, ErrPrivateNetwork),
+		errors.Is(err, ErrTooManyRedirects),
+		errors.Is(err, ErrInsecureRedirect),
+		errors.Is(err, ErrRedirectLoop),
+		errors.Is(err, ErrPermanentlyMoved),
+		errors.Is(err, ErrBlockedDomain),
+		errors.Is(err, ErrDomainNotAllowed),
+		errors.Is(err, ErrUnexpectedContentType):
+		return false
+	}
+
+	var feedErr *FeedError
+	return errors.As(err, &feedErr) && feedErr.Phase == PhaseDownload && isTransientNetworkError(err)
+}
+
+// isTransientNetworkError reports whether err is a timeout or a failure to
+// reach or keep talking to the server, rather than one that repeats on every
+// attempt, such as a certificate that does not verify, an unknown host or an
+// unsupported url scheme.
+func isTransientNetworkError(err error) bool {
+	var (
+		certErr *tls.CertificateVerificationError
+		dnsErr  *net.DNSError
+		opErr   *net.OpError
+		netErr  net.Error
+	)
+	switch {
+	case errors.As(err, &certErr):
+		return false
+	case errors.As(err, &dnsErr):
+		return !dnsErr.IsNotFound
+	case errors.As(err, &opErr),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET):
+		return true
+	default:
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+}
+
+// retryAfter returns the wait asked for by the Retry-After header of a 429
+// or 503 response, given in seconds or as an HTTP date.
+func retryAfter(err error, now time.Time) (time.Duration, bool) {
+	var statusErr *feedparser.StatusError
+	if !errors.As(err, &statusErr) ||
+		(statusErr.StatusCode != http.StatusTooManyRequests && statusErr.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+
+	value := statusErr.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		// Clamped so a huge value cannot overflow into a short wait
+		seconds = min(max(seconds, 0), int(maxRetryAfter/time.Second)+1)
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// WithRetryableStatusCodes returns a new FeedFetcher that retries responses
+// with exactly the given status codes, instead of the default of any 5xx and
+// 429. Include the defaults you want to keep. Calling it without codes
+// restores the default set.
+//
+// Retrying a 4xx other than 429 is rarely useful and can get a client
+// blocked: most mean the request itself is wrong, and a 403 from a firewall
+// may be answered with a longer ban when it is repeated. Keep MaxRetries low
+// and RetryBackoff generous when adding them.
+func (f *FeedFetcher) WithRetryableStatusCodes(codes ...int) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.retryableStatus = nil
+	if len(codes) > 0 {
+		newFetcher.retryableStatus = make(map[int]bool, len(codes))
+		for _, code := range codes {
+			newFetcher.retryableStatus[code] = true
+		}
+	}
+	return &newFetcher
+}
+
+// sleep waits for d, returning early with ctx's error if it is done first.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// feedRootElements are the root elements of RSS, Atom and RSS 1.0 (RDF)
+// documents, lowercased.
+var feedRootElements = [][]byte{[]byte("<rss"), []byte("<feed"), []byte("<rdf:rdf")}
+
+// isSoftError reports whether a response body that failed to parse is an
+// HTML page, typically an error page served with status 200. The heuristic:
+// the response is declared as text/html or starts with a doctype or <html>
+// tag, and contains none of the root elements of RSS, Atom or RDF feeds.
+func isSoftError(contentType string, body []byte) bool {
+	lower := bytes.ToLower(bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf"))))
+
+	isHTML := contentType == "text/html" ||
+		bytes.HasPrefix(lower, []byte("<!doctype html")) ||
+		bytes.HasPrefix(lower, []byte("<html"))
+	if !isHTML {
+		return false
+	}
+
+	for _, root := range feedRootElements {
+		if bytes.Contains(lower, root) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,336 @@
+package feedfetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+)
+
+const errorPage = `<!DOCTYPE html><html><head><title>Error</title></head><body>Service unavailable</body></html>`
+
+// flakyServer fails the first failures requests with status, or with an
+// HTML page served as 200 if status is 0, and serves a feed afterwards.
+func flakyServer(t *testing.T, failures int, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(requests.Add(1)) <= failures {
+			if status == 0 {
+				w.Header().Set("Content-Type", "text/html; charset=utf-8")
+				fmt.Fprint(w, errorPage)
+				return
+			}
+			w.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(w, rssDocument(time.Now(), "Recovered"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func retryConfig(maxRetries int) Config {
+	config := DefaultConfig
+	config.MaxRetries = maxRetries
+	config.RetryBackoff = time.Millisecond
+	return config
+}
+
+func TestFeedFetcher_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		failures     int
+		maxRetries   int
+		wantErr      bool
+		wantRequests int32
+	}{
+		{"no retries by default", http.StatusServiceUnavailable, 1, 0, true, 1},
+		{"recovers from 5xx", http.StatusServiceUnavailable, 2, 2, false, 3},
+		{"recovers from 429", http.StatusTooManyRequests, 1, 2, false, 2},
+		{"gives up after MaxRetries", http.StatusBadGateway, 5, 2, true, 3},
+		{"4xx is not retried", http.StatusNotFound, 1, 2, true, 1},
+		{"soft errors not retried by default", 0, 1, 2, true, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := flakyServer(t, tt.failures, tt.status)
+			items, err := NewFeedFetcher(retryConfig(tt.maxRetries)).FetchAndProcess(context.Background(), server.URL)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, items, 1)
+			}
+			assert.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+func TestFeedFetcher_RetrySoftErrors(t *testing.T) {
+	server, requests := flakyServer(t, 1, 0)
+	_, err := NewFeedFetcher(retryConfig(0)).FetchAndProcess(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrSoftError)
+	var feedErr *FeedError
+	require.ErrorAs(t, err, &feedErr)
+	assert.Equal(t, PhaseParse, feedErr.Phase)
+
+	server, requests = flakyServer(t, 1, 0)
+	config := retryConfig(1)
+	config.RetrySoftErrors = true
+	items, err := NewFeedFetcher(config).FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestFeedFetcher_MaxTotalFetchTime(t *testing.T) {
+	config := retryConfig(10)
+	config.RetryBackoff = 20 * time.Millisecond
+	config.MaxTotalFetchTime = 100 * time.Millisecond
+
+	t.Run("stops retrying", func(t *testing.T) {
+		server, requests := flakyServer(t, 100, http.StatusServiceUnavailable)
+		start := time.Now()
+		_, err := NewFeedFetcher(config).FetchAndProcess(context.Background(), server.URL)
+		elapsed := time.Since(start)
+
+		var httpErr gofeed.HTTPError
+		require.ErrorAs(t, err, &httpErr, "the last error is returned, not a timeout")
+		assert.Equal(t, http.StatusServiceUnavailable, httpErr.StatusCode)
+		assert.Less(t, elapsed, time.Second)
+		assert.Greater(t, requests.Load(), int32(1))
+		assert.Less(t, requests.Load(), int32(11))
+	})
+
+	t.Run("cuts a slow attempt short", func(t *testing.T) {
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if requests.Add(1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+
+		start := time.Now()
+		_, err := NewFeedFetcher(config).FetchAndProcess(context.Background(), server.URL)
+		var httpErr gofeed.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusBadGateway, httpErr.StatusCode)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestFeedFetcher_RetryBackoffCanceled(t *testing.T) {
+	server, requests := flakyServer(t, 100, http.StatusServiceUnavailable)
+	config := retryConfig(3)
+	config.RetryBackoff = 10 * time.Second
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		for requests.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(20 * time.Millisecond) // well into the first backoff
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := NewFeedFetcher(config).FetchAndProcess(ctx, server.URL)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second, "backoff must not be waited out")
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestFeedFetcher_WithRetryableStatusCodes(t *testing.T) {
+	fetcher := NewFeedFetcher(retryConfig(2)).WithRetryableStatusCodes(http.StatusForbidden, http.StatusServiceUnavailable)
+
+	server, requests := flakyServer(t, 2, http.StatusForbidden)
+	items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.Equal(t, int32(3), requests.Load())
+
+	server, requests = flakyServer(t, 1, http.StatusBadGateway)
+	_, err = fetcher.FetchAndProcess(context.Background(), server.URL)
+	assert.Error(t, err, "502 is no longer in the set")
+	assert.Equal(t, int32(1), requests.Load())
+
+	server, requests = flakyServer(t, 1, http.StatusBadGateway)
+	_, err = fetcher.WithRetryableStatusCodes().FetchAndProcess(context.Background(), server.URL)
+	assert.NoError(t, err, "defaults restored")
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestFeedFetcher_RetryAfter(t *testing.T) {
+	var requests atomic.Int32
+	var retryAfter atomic.Value
+	retryAfter.Store("1")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.Header().Set("Retry-After", retryAfter.Load().(string))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		fmt.Fprint(w, rssDocument(time.Now(), "Recovered"))
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	items, err := NewFeedFetcher(retryConfig(2)).FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second, "Retry-After outranks RetryBackoff")
+	assert.Equal(t, int32(2), requests.Load())
+
+	t.Run("too long to wait", func(t *testing.T) {
+		requests.Store(0)
+		retryAfter.Store("3600")
+		_, err := NewFeedFetcher(retryConfig(2)).FetchAndProcess(context.Background(), server.URL)
+		var httpErr gofeed.HTTPError
+		require.ErrorAs(t, err, &httpErr)
+		assert.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	statusErr := func(status int, value string) error {
+		header := http.Header{}
+		if value != "" {
+			header.Set("Retry-After", value)
+		}
+		return newFeedError("https://example.com/feed", PhaseDownload, &feedparser.StatusError{
+			HTTPError: gofeed.HTTPError{StatusCode: status},
+			Header:    header,
+		})
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		want   time.Duration
+		wantOK bool
+	}{
+		{"seconds", statusErr(http.StatusTooManyRequests, "120"), 2 * time.Minute, true},
+		{"http date", statusErr(http.StatusServiceUnavailable, "Wed, 01 May 2024 12:00:30 GMT"), 30 * time.Second, true},
+		{"date in the past", statusErr(http.StatusTooManyRequests, "Wed, 01 May 2024 11:00:00 GMT"), 0, true},
+		{"huge", statusErr(http.StatusTooManyRequests, "99999999999999"), maxRetryAfter + time.Second, true},
+		{"no header", statusErr(http.StatusTooManyRequests, ""), 0, false},
+		{"garbage", statusErr(http.StatusTooManyRequests, "soon"), 0, false},
+		{"other status", statusErr(http.StatusInternalServerError, "120"), 0, false},
+		{"not a status error", errors.New("boom"), 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := retryAfter(tt.err, now)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	downloadErr := func(err error) error {
+		return newFeedError("https://example.com/feed", PhaseDownload,
+			&url.Error{Op: "Get", URL: "https://example.com/feed", Err: err})
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"connection refused", downloadErr(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true},
+		{"connection reset", downloadErr(syscall.ECONNRESET), true},
+		{"unexpected EOF", downloadErr(io.ErrUnexpectedEOF), true},
+		{"timeout", newFeedError("https://example.com/feed", PhaseDownload,
+			fmt.Errorf("timed out after %v: %w", time.Second, context.DeadlineExceeded)), true},
+		{"DNS timeout", downloadErr(&net.DNSError{Err: "i/o timeout", Name: "example.com", IsTimeout: true}), true},
+		{"unknown host", downloadErr(&net.DNSError{Err: "no such host", Name: "example.com", IsNotFound: true}), false},
+		{"certificate not verified", downloadErr(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), false},
+		{"unsupported scheme", downloadErr(errors.New(`unsupported protocol scheme "gopher"`)), false},
+		{"server error", statusError(http.StatusBadGateway), true},
+		{"not found", statusError(http.StatusNotFound), false},
+		{"parse error", newFeedError("https://example.com/feed", PhaseParse, io.ErrUnexpectedEOF), false},
+	}
+	fetcher := NewFeedFetcher(retryConfig(2))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fetcher.isRetryable(fetcher.config, tt.err))
+		})
+	}
+}
+
+func statusError(status int) error {
+	return newFeedError("https://example.com/feed", PhaseDownload, gofeed.HTTPError{StatusCode: status})
+}
+
+func TestFeedFetcher_CertificateErrorNotRetried(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "Untrusted"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	defer server.Close()
+
+	_, err := NewFeedFetcher(retryConfig(2)).FetchAndProcess(context.Background(), server.URL)
+	var certErr *tls.CertificateVerificationError
+	require.ErrorAs(t, err, &certErr)
+	assert.Equal(t, int32(1), conns.Load())
+}
+
+func TestIsSoftError(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        bool
+	}{
+		{"html error page", "text/html", errorPage, true},
+		{"html sniffed from body", "application/rss+xml", "\xef\xbb\xbf\n  <HTML><body>Oops</body></HTML>", true},
+		{"html type with unrecognized body", "text/html", "Internal error", true},
+		{"broken rss", "application/rss+xml", `<?xml version="1.0"?><rss><channel>`, false},
+		{"rss served as html", "text/html", `<rss version="2.0"><channel></channel>`, false},
+		{"atom inside html", "text/html", `<html><feed xmlns="http://www.w3.org/2005/Atom">`, false},
+		{"plain text", "text/plain", "not a feed", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isSoftError(tt.contentType, []byte(tt.body)))
+		})
+	}
+}
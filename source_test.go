@@ -0,0 +1,59 @@
+package feedfetcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertItems_Source(t *testing.T) {
+	published := time.Now().UTC().Format(time.RFC1123Z)
+	updated := time.Now().UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		doc      string
+		wantName string
+		wantURL  string
+	}{
+		{
+			name: "rss source",
+			doc: `<rss version="2.0"><channel><title>Aggregator</title><item><title>Story</title>
+				<link>https://agg.example.com/1</link><pubDate>` + published + `</pubDate>
+				<source url="/origin/feed.xml"> Origin News </source></item></channel></rss>`,
+			wantName: "Origin News",
+			wantURL:  "https://agg.example.com/origin/feed.xml",
+		},
+		{
+			name: "atom source",
+			doc: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Aggregator</title><entry><title>Story</title>
+				<link href="https://agg.example.com/1"/><updated>` + updated + `</updated>
+				<source><title>Origin News</title><link rel="self" href="https://origin.example.com/feed"/>
+				<link href="https://origin.example.com/"/></source></entry></feed>`,
+			wantName: "Origin News",
+			wantURL:  "https://origin.example.com/",
+		},
+		{
+			name: "rss without source",
+			doc: `<rss version="2.0"><channel><title>Blog</title><item><title>Post</title>
+				<link>https://agg.example.com/2</link><pubDate>` + published + `</pubDate></item></channel></rss>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := NewDefaultFeedFetcher()
+			data, err := fetcher.parser.Parse(strings.NewReader(tt.doc))
+			require.NoError(t, err)
+
+			items, itemErrs := ConvertItems(mustParseURL(t, "https://agg.example.com/feed"), data.Items, DefaultConfig)
+			require.Empty(t, itemErrs)
+			require.Len(t, items, 1)
+			assert.Equal(t, tt.wantName, items[0].SourceName)
+			assert.Equal(t, tt.wantURL, items[0].SourceURL)
+		})
+	}
+}
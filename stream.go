@@ -0,0 +1,68 @@
+package feedfetcher
+
+// streamItems converts the feed's items and hands them to feed.opts.OnItems
+// in batches, holding no more than one batch of converted items at a time.
+func (f *FeedFetcher) streamItems(feed *feed, config Config) error {
+	size := feed.opts.ItemBufferSize
+	if size <= 0 {
+		size = defaultItemBufferSize
+	}
+
+	var (
+		batch   = make([]*FeedItem, 0, size)
+		guids   = make(map[string]bool) // GUIDs of accepted items, true once repeated
+		failure error
+	)
+	flush := func() {
+		if items := f.finishItems(feed, batch); len(items) > 0 {
+			feed.opts.OnItems(items)
+		}
+		batch = make([]*FeedItem, 0, size)
+	}
+
+	convertEach(feed.parsedURL, feed.siteURL(), feed.data.Items, config, func(item *FeedItem, itemErr *ItemError) bool {
+		if itemErr != nil {
+			feed.itemErrs = append(feed.itemErrs, *itemErr)
+			if failure = f.dateFormatError(feed, *itemErr); failure != nil {
+				return false
+			}
+			if config.StrictValidation && !isFiltered(itemErr.Err) {
+				failure = newFeedError(feed.url, PhaseValidate, *itemErr)
+				return false
+			}
+			return true
+		}
+
+		if item.GUID != "" {
+			if _, seen := guids[item.GUID]; seen {
+				item.DuplicateGUID = true
+				guids[item.GUID] = true
+			} else {
+				guids[item.GUID] = false
+			}
+		}
+		batch = append(batch, item)
+		if len(batch) >= size {
+			markBatchGUIDs(batch, guids)
+			flush()
+		}
+		return true
+	})
+	if failure != nil {
+		return failure
+	}
+
+	markBatchGUIDs(batch, guids)
+	flush()
+	return nil
+}
+
+// markBatchGUIDs flags the items of a batch about to be delivered whose GUID
+// turned out to be repeated later in the same batch.
+func markBatchGUIDs(batch []*FeedItem, repeated map[string]bool) {
+	for _, item := range batch {
+		if item.GUID != "" && repeated[item.GUID] {
+			item.DuplicateGUID = true
+		}
+	}
+}
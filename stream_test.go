@@ -0,0 +1,82 @@
+package feedfetcher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedFetcher_OnItems(t *testing.T) {
+	now := time.Now()
+	var items []*gofeed.Item
+	for i := range 250 {
+		items = append(items, &gofeed.Item{
+			Title:           fmt.Sprintf("Item %d", i),
+			Link:            fmt.Sprintf("/%d", i),
+			GUID:            fmt.Sprintf("guid-%d", i%240), // the last 10 repeat the first 10
+			PublishedParsed: timePtr(now),
+		})
+	}
+	items[5].Title = "" // dropped
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: items}, nil
+		},
+	}).WithMaxItems(0)
+
+	var batches [][]*FeedItem
+	got, err := fetcher.FetchAndProcessWithOptions(context.Background(), "https://example.com/feed", FetchOptions{
+		ItemBufferSize: 100,
+		OnItems:        func(items []*FeedItem) { batches = append(batches, items) },
+	})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+
+	require.Len(t, batches, 3)
+	assert.Len(t, batches[0], 100)
+	assert.Len(t, batches[1], 100)
+	assert.Len(t, batches[2], 49)
+	assert.Equal(t, "Item 0", batches[0][0].Headline)
+	assert.Equal(t, "Item 249", batches[2][48].Headline)
+	assert.False(t, batches[0][0].DuplicateGUID, "its duplicate came in a later batch")
+	assert.True(t, batches[2][48].DuplicateGUID)
+
+	result, err := fetcher.FetchFull(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Len(t, result.Items, 249, "other fetches are unaffected")
+	assert.True(t, result.Items[0].DuplicateGUID)
+}
+
+func TestFeedFetcher_OnItems_Failure(t *testing.T) {
+	now := time.Now()
+	items := []*gofeed.Item{
+		{Title: "First", Link: "/1", PublishedParsed: timePtr(now)},
+		{Title: "Second", Link: "/2", PublishedParsed: timePtr(now)},
+		{Title: "Undated", Link: "/3", Published: "sometime last week"},
+		{Title: "Fourth", Link: "/4", PublishedParsed: timePtr(now)},
+	}
+	fetcher := NewFeedFetcherWithParser(DefaultConfig, &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: items}, nil
+		},
+	})
+
+	var delivered []string
+	ff, _, err := fetcher.fetch(context.Background(), "https://example.com/feed", FetchOptions{
+		ItemBufferSize: 1,
+		OnItems: func(items []*FeedItem) {
+			for _, item := range items {
+				delivered = append(delivered, item.Headline)
+			}
+		},
+	})
+	assert.ErrorIs(t, err, ErrFeedPublicationDateFormat)
+	assert.Equal(t, []string{"First", "Second"}, delivered, "batches before the failure were delivered")
+	require.NotNil(t, ff)
+	assert.Equal(t, 2, ff.stats.ItemsAccepted)
+}
@@ -0,0 +1,68 @@
+package feedfetcher
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// blockTags separate words even when the markup has no whitespace around
+// them; inline tags such as <b> do not.
+var blockTags = map[string]bool{
+	"br": true, "p": true, "div": true, "li": true, "ul": true, "ol": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "tr": true, "td": true, "th": true,
+	"hr": true, "img": true, "figure": true, "figcaption": true,
+}
+
+// summarize returns the text of an HTML fragment, with whitespace collapsed,
+// cut to its first maxWords words. Truncated summaries end in "…".
+func summarize(content string, maxWords int) string {
+	words := strings.Fields(htmlText(content))
+	if len(words) <= maxWords {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words[:maxWords], " ") + "…"
+}
+
+// htmlText returns the text of an HTML fragment with entities decoded and
+// the contents of script and style elements dropped.
+func htmlText(content string) string {
+	var b strings.Builder
+	hidden := 0 // depth of open script and style elements
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		switch tt := z.Next(); tt {
+		case html.ErrorToken:
+			return b.String()
+		case html.TextToken:
+			if hidden == 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			switch tag := string(name); {
+			case tag == "script" || tag == "style":
+				if tt == html.StartTagToken {
+					hidden++
+				} else if hidden > 0 {
+					hidden--
+				}
+			case blockTags[tag]:
+				b.WriteByte(' ')
+			}
+		}
+	}
+}
+
+// textLength returns the length in runes of the text of an HTML fragment,
+// with whitespace collapsed and trimmed as in summarize.
+func textLength(content string) int {
+	words := strings.Fields(htmlText(content))
+	n := max(len(words)-1, 0)
+	for _, word := range words {
+		n += utf8.RuneCountInString(word)
+	}
+	return n
+}
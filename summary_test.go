@@ -0,0 +1,113 @@
+package feedfetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		maxWords int
+		want     string
+	}{
+		{"plain text fits", "Short and sweet", 5, "Short and sweet"},
+		{"cut at word boundary", "one two three four five six", 4, "one two three four…"},
+		{"exactly the limit", "one two three", 3, "one two three"},
+		{"markup stripped", `<p>Hello <b>bold</b> <a href="/x">world</a></p>`, 10, "Hello bold world"},
+		{"inline tags keep words whole", "un<em>believ</em>able news", 10, "unbelievable news"},
+		{"block tags separate words", "<p>end</p><p>start</p>line<br>break", 10, "end start line break"},
+		{"entities decoded", "Fish &amp; chips&nbsp;today", 10, "Fish & chips today"},
+		{"scripts and styles dropped", "<style>p{}</style>Visible<script>alert(1)</script> text", 10, "Visible text"},
+		{"whitespace collapsed", "  lots \n\n of\tspace  ", 10, "lots of space"},
+		{"markup does not count as words", `<div class="a b c"><img src="x.png" alt="pic">one two three</div>`, 2, "one two…"},
+		{"empty", "", 10, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, summarize(tt.content, tt.maxWords))
+		})
+	}
+}
+
+func TestFeedFetcher_SummaryWords(t *testing.T) {
+	pubDate := time.Now().Add(-time.Hour)
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{
+					Title: "Both", Link: "/both", PublishedParsed: &pubDate,
+					Description: "<p>The short summary.</p>",
+					Content:     "<p>The full body of the article, which goes on and on.</p>",
+				},
+				{
+					Title: "Content only", Link: "/content", PublishedParsed: &pubDate,
+					Content: "<p>The full body of the article, which goes on and on.</p>",
+				},
+			}}, nil
+		},
+	}
+
+	config := DefaultConfig
+	config.SummaryWords = 5
+	items, err := NewFeedFetcherWithParser(config, mockParser).FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "The short summary.", items[0].Summary, "description preferred")
+	assert.Equal(t, "The full body of the…", items[1].Summary)
+
+	items, err = NewFeedFetcherWithParser(DefaultConfig, mockParser).FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Equal(t, "<p>The short summary.</p>", items[0].Summary, "the feed's summary is kept as is")
+	assert.Equal(t, "<p>The full body of the article, which goes on and on.</p>", items[0].Content)
+	assert.Equal(t, items[0].Summary, items[0].SummaryOrContent())
+	assert.Empty(t, items[1].Summary)
+	assert.Equal(t, items[1].Content, items[1].SummaryOrContent())
+}
+
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 0, textLength(""))
+	assert.Equal(t, 0, textLength("<p> </p><script>var x = 1</script>"))
+	assert.Equal(t, 5, textLength("<p>Hello</p>"))
+	assert.Equal(t, 11, textLength("  <b>Héllo</b>\n\t wörld  "))
+	assert.Equal(t, 3, textLength("a&amp;b"))
+}
+
+func TestFeedFetcher_MinContentLength(t *testing.T) {
+	pubDate := time.Now().Add(-time.Hour)
+	mockParser := &MockFeedParser{
+		MockParseFn: func(url string, ctx context.Context) (*gofeed.Feed, error) {
+			return &gofeed.Feed{Items: []*gofeed.Item{
+				{
+					Title: "A long headline that does not count", Link: "/short", PublishedParsed: &pubDate,
+					Description: "<p>Read more</p>", Content: "<p><a href=\"/short\">Link</a></p>",
+				},
+				{
+					Title: "Full", Link: "/full", PublishedParsed: &pubDate,
+					Description: "<p>The full body of the article, which goes on and on.</p>",
+				},
+			}}, nil
+		},
+	}
+
+	items, err := NewFeedFetcherWithParser(DefaultConfig, mockParser).FetchAndProcess(context.Background(), "https://example.com/feed")
+	require.NoError(t, err)
+	assert.Len(t, items, 2, "no minimum by default")
+
+	config := DefaultConfig
+	config.MinContentLength = 20
+	config.StrictValidation = true
+	result, err := NewFeedFetcherWithParser(config, mockParser).FetchFull(context.Background(), "https://example.com/feed")
+	require.NoError(t, err, "a filter, not a validation failure")
+	require.Len(t, result.Items, 1)
+	assert.Equal(t, "Full", result.Items[0].Headline)
+	require.Len(t, result.ItemErrors, 1)
+	assert.ErrorIs(t, result.ItemErrors[0], ErrContentTooShort)
+}
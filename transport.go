@@ -0,0 +1,282 @@
+package feedfetcher
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/proxy"
+
+	"github.com/reddot-watch/feedfetcher/internal/dnscache"
+	"github.com/reddot-watch/feedfetcher/internal/feedparser"
+)
+
+// transportOptions holds the settings used to build the fetcher's HTTP
+// client that are not part of Config.
+type transportOptions struct {
+	socks5Addr string        // SOCKS5 proxy address; empty disables the proxy
+	socks5Auth *proxy.Auth   // SOCKS5 credentials, if any
+	resolver   *net.Resolver // resolves host names; nil uses net.DefaultResolver
+	tlsConfig  *tls.Config   // TLS settings; nil uses the defaults
+
+	dnsLookup DNSLookupFunc   // fills the DNS cache instead of resolver, if set
+	dnsCache  *dnscache.Cache // shared by the fetchers derived from this one
+}
+
+// maxDNSCacheEntries bounds the hosts a fetcher's DNS cache remembers.
+const maxDNSCacheEntries = 4096
+
+// DNSLookupFunc resolves host to its addresses and reports the smallest TTL
+// of the records in the answer. See FeedFetcher.WithDNSLookup.
+type DNSLookupFunc func(ctx context.Context, host string) (addrs []netip.Addr, ttl time.Duration, err error)
+
+// newHTTPClient builds the HTTP client used to download feeds.
+func newHTTPClient(config Config, opts transportOptions) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.BlockPrivateNetworks || config.DNSCacheTTL > 0 || opts.resolver != nil {
+		// Same settings as http.DefaultTransport's dialer
+		dialer := &net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Resolver:  opts.resolver,
+		}
+		if config.BlockPrivateNetworks {
+			dialer.Control = rejectPrivateAddress
+		}
+		transport.DialContext = dialer.DialContext
+		if config.DNSCacheTTL > 0 {
+			transport.DialContext = cachedDial(dialer, opts.dnsCache)
+		}
+	}
+
+	if opts.socks5Addr != "" {
+		// SOCKS5 only fails for unsupported networks, and "tcp" is supported
+		dialer, _ := proxy.SOCKS5("tcp", opts.socks5Addr, opts.socks5Auth, proxy.Direct)
+
+		// Every connection is tunneled through the SOCKS5 proxy, so HTTP
+		// proxy settings from the environment no longer apply.
+		transport.Proxy = nil
+		transport.DialContext = dialer.(proxy.ContextDialer).DialContext
+	}
+
+	if opts.tlsConfig != nil {
+		transport.TLSClientConfig = opts.tlsConfig.Clone()
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// cachedDial returns a DialContext function that resolves host names through
+// cache and dials the resulting addresses in turn. The dialer's Control
+// function still sees every address, so cached answers are subject to the
+// same private network checks as fresh ones.
+func cachedDial(dialer *net.Dialer, cache *dnscache.Cache) func(context.Context, string, string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, err
+		}
+		if net.ParseIP(host) != nil {
+			return dialer.DialContext(ctx, network, address)
+		}
+
+		addrs, err := cache.LookupHost(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []error
+		for _, addr := range addrs {
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(addr.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		if len(errs) == 0 {
+			return nil, fmt.Errorf("dial %s: no addresses for %s", network, host)
+		}
+		return nil, errors.Join(errs...)
+	}
+}
+
+// rejectPrivateAddress is a net.Dialer Control function that refuses to
+// connect to private, loopback, link-local or unspecified addresses. It runs
+// after name resolution, so a host name cannot be rebound to an internal
+// address between a check and the connection.
+func rejectPrivateAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: unexpected address %q", ErrPrivateNetwork, address)
+	}
+	if ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("%w: %s", ErrPrivateNetwork, ip)
+	}
+	return nil
+}
+
+// checkRedirect returns an http.Client.CheckRedirect function enforcing the
+// redirect limit, the downgrade policy and the domain access lists of config,
+// and stopping at loops and, if config asks for it, permanent redirects of
+// the feed url. Those are recorded in moves to catch loops across fetches.
+func checkRedirect(config Config, moves *movedFeeds) func(*http.Request, []*http.Request) error {
+	maxRedirects := config.MaxRedirects
+	if maxRedirects == 0 {
+		maxRedirects = 10
+	}
+
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: stopped after %d", ErrTooManyRedirects, len(via)-1)
+		}
+
+		target := req.URL.String()
+		for _, prev := range via {
+			if prev.URL.String() == target {
+				return fmt.Errorf("%w: back to %s", ErrRedirectLoop, target)
+			}
+		}
+
+		from := via[len(via)-1].URL.Scheme
+		if !config.AllowInsecureRedirect && isDowngrade(from, req.URL.Scheme) {
+			return fmt.Errorf("%w: %s to %s", ErrInsecureRedirect, from, req.URL.Scheme)
+		}
+
+		if err := checkHost(config, req.URL.Hostname()); err != nil {
+			return fmt.Errorf("redirect to %s: %w", req.URL.Host, err)
+		}
+
+		if config.ReportPermanentRedirect && len(via) == 1 && isPermanentRedirect(req.Response) {
+			feedURL := via[0].URL.String()
+			if moves != nil && !moves.move(feedURL, target) {
+				return fmt.Errorf("%w: %s moved back to %s", ErrRedirectLoop, feedURL, target)
+			}
+			return &PermanentRedirectError{FeedURL: feedURL, Location: target}
+		}
+		return nil
+	}
+}
+
+// isDowngrade reports whether moving from one URL scheme to another loses
+// transport security or leaves http altogether.
+func isDowngrade(from, to string) bool {
+	if to != "http" && to != "https" {
+		return true
+	}
+	return from == "https" && to == "http"
+}
+
+// newParser builds the feed parser for the fetcher's user agent, domain
+// access lists and transport settings.
+func (f *FeedFetcher) newParser() feedparser.Parser {
+	f.transport.dnsCache = f.dnsCache()
+	client := newHTTPClient(f.config, f.transport)
+	client.CheckRedirect = checkRedirect(f.config, f.moves)
+	return feedparser.NewGoFeedParserWithClient(f.config.UserAgent, client)
+}
+
+// dnsCache returns the DNS cache for the fetcher's settings: the one it
+// already has, so fetchers derived from each other share their lookups,
+// unless Config.DNSCacheTTL changed or the cache was dropped along with the
+// resolver that filled it.
+func (f *FeedFetcher) dnsCache() *dnscache.Cache {
+	ttl := f.config.DNSCacheTTL
+	switch {
+	case ttl <= 0:
+		return nil
+	case f.transport.dnsCache != nil && f.transport.dnsCache.TTL() == ttl:
+		return f.transport.dnsCache
+	case f.transport.dnsLookup != nil:
+		return dnscache.NewWithLookup(dnscache.LookupFunc(f.transport.dnsLookup), ttl, maxDNSCacheEntries)
+	default:
+		return dnscache.New(f.transport.resolver, ttl, maxDNSCacheEntries)
+	}
+}
+
+// WithResolver returns a new FeedFetcher that resolves host names with
+// resolver instead of net.DefaultResolver, e.g. one dialing a specific DNS
+// server. It is also used to fill the DNS cache when Config.DNSCacheTTL is
+// set, and is ignored for fetches through a SOCKS5 proxy.
+func (f *FeedFetcher) WithResolver(resolver *net.Resolver) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.transport.resolver = resolver
+	newFetcher.transport.dnsCache = nil
+	newFetcher.parser = newFetcher.newParser()
+	return &newFetcher
+}
+
+// WithDNSLookup returns a new FeedFetcher that fills the DNS cache with
+// lookup instead of the resolver. Unlike Go's resolver, lookup reports the
+// TTL of the records it returns, so each answer is cached for that TTL,
+// capped at Config.DNSCacheTTL; answers with a zero TTL are not cached.
+// lookup is only used when Config.DNSCacheTTL is set. Pass nil to go back to
+// the resolver.
+func (f *FeedFetcher) WithDNSLookup(lookup DNSLookupFunc) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.transport.dnsLookup = lookup
+	newFetcher.transport.dnsCache = nil
+	newFetcher.parser = newFetcher.newParser()
+	return &newFetcher
+}
+
+// WithSOCKS5Proxy returns a new FeedFetcher that downloads feeds through the
+// SOCKS5 proxy at addr (host:port). Leave username and password empty if the
+// proxy does not require authentication. Host names are resolved by the
+// proxy. The new fetcher gets its own HTTP transport, so HTTP proxies
+// configured through the environment are no longer used.
+func (f *FeedFetcher) WithSOCKS5Proxy(addr, username, password string) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.transport.socks5Addr = addr
+	newFetcher.transport.socks5Auth = nil
+	if username != "" || password != "" {
+		newFetcher.transport.socks5Auth = &proxy.Auth{User: username, Password: password}
+	}
+	newFetcher.parser = newFetcher.newParser()
+	return &newFetcher
+}
+
+// WithTLSConfig returns a new FeedFetcher that makes TLS connections with a
+// copy of config, e.g. to trust a private certificate authority through
+// RootCAs or to present client certificates. It applies to every feed the
+// fetcher downloads, including through a SOCKS5 proxy, which only tunnels
+// the connection. The package never skips certificate verification on its
+// own; setting InsecureSkipVerify in config is the only way to. Pass nil to
+// go back to the default settings.
+func (f *FeedFetcher) WithTLSConfig(config *tls.Config) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.transport.tlsConfig = nil
+	if config != nil {
+		newFetcher.transport.tlsConfig = config.Clone()
+	}
+	newFetcher.parser = newFetcher.newParser()
+	return &newFetcher
+}
+
+// WithClientCertificate returns a new FeedFetcher that presents cert to
+// servers asking for a client certificate, for feeds behind mutual TLS. It
+// replaces any certificates of an earlier WithTLSConfig and keeps its other
+// settings. Every server the fetcher connects to may request the
+// certificate, so use a separate fetcher for the feeds that need it.
+func (f *FeedFetcher) WithClientCertificate(cert tls.Certificate) *FeedFetcher {
+	config := &tls.Config{}
+	if f.transport.tlsConfig != nil {
+		config = f.transport.tlsConfig.Clone()
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return f.WithTLSConfig(config)
+}
@@ -0,0 +1,365 @@
+package feedfetcher
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
+)
+
+// startSOCKS5Server runs a minimal SOCKS5 proxy that requires the given
+// credentials and supports CONNECT only. It returns the proxy address and a
+// channel receiving the destination of every tunneled connection.
+func startSOCKS5Server(t *testing.T, username, password string) (string, <-chan string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	destinations := make(chan string, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSOCKS5(conn, username, password, destinations)
+		}
+	}()
+
+	return ln.Addr().String(), destinations
+}
+
+func serveSOCKS5(conn net.Conn, username, password string, destinations chan<- string) {
+	defer conn.Close()
+
+	// Greeting: version, method count, methods. Require username/password.
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, make([]byte, header[1])); err != nil {
+		return
+	}
+	conn.Write([]byte{0x05, 0x02})
+
+	// Username/password sub-negotiation
+	readField := func() string {
+		size := make([]byte, 1)
+		io.ReadFull(conn, size)
+		field := make([]byte, size[0])
+		io.ReadFull(conn, field)
+		return string(field)
+	}
+	io.ReadFull(conn, make([]byte, 1))
+	if readField() != username || readField() != password {
+		conn.Write([]byte{0x01, 0x01})
+		return
+	}
+	conn.Write([]byte{0x01, 0x00})
+
+	// CONNECT request
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return
+	}
+	var host string
+	switch request[3] {
+	case 0x01:
+		ip := make([]byte, 4)
+		io.ReadFull(conn, ip)
+		host = net.IP(ip).String()
+	case 0x03:
+		host = readField()
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(conn, port)
+	destination := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port))))
+	destinations <- destination
+
+	upstream, err := net.Dial("tcp", destination)
+	if err != nil {
+		conn.Write([]byte{0x05, 0x05, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer upstream.Close()
+	conn.Write([]byte{0x05, 0x00, 0x00, 0x01, 0, 0, 0, 0, 0, 0})
+
+	go io.Copy(upstream, conn)
+	io.Copy(conn, upstream)
+}
+
+func TestFeedFetcher_WithSOCKS5Proxy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "Proxied"))
+	}))
+	defer server.Close()
+
+	proxyAddr, destinations := startSOCKS5Server(t, "user", "secret")
+
+	t.Run("valid credentials", func(t *testing.T) {
+		fetcher := NewDefaultFeedFetcher().WithSOCKS5Proxy(proxyAddr, "user", "secret")
+
+		items, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+		assert.Equal(t, server.Listener.Addr().String(), <-destinations)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		fetcher := NewDefaultFeedFetcher().WithSOCKS5Proxy(proxyAddr, "user", "wrong")
+
+		_, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+		assert.Error(t, err)
+	})
+}
+
+func TestFeedFetcher_BlockPrivateNetworks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "Internal"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	config := DefaultConfig
+	config.BlockPrivateNetworks = true
+	config.RequestTimeout = time.Second
+	fetcher := NewFeedFetcherWithParser(config, nil)
+	fetcher.parser = fetcher.newParser()
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"loopback", server.URL},
+		{"private", "http://10.0.0.1/feed"},
+		{"link-local", "http://169.254.169.254/latest/meta-data"},
+		{"host name resolving to loopback", "http://localhost:" + port + "/feed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fetcher.FetchAndProcess(context.Background(), tt.url)
+			assert.ErrorIs(t, err, ErrPrivateNetwork)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		items, err := NewDefaultFeedFetcher().FetchAndProcess(context.Background(), server.URL)
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	})
+}
+
+func TestFeedFetcher_DNSCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "Cached"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	feedURL := "http://localhost:" + port + "/feed"
+
+	config := DefaultConfig
+	config.DNSCacheTTL = time.Minute
+	fetcher := NewDefaultFeedFetcher().WithResolver(&net.Resolver{PreferGo: true})
+	fetcher.config = config
+	fetcher.parser = fetcher.newParser()
+
+	for range 2 {
+		items, err := fetcher.FetchAndProcess(context.Background(), feedURL)
+		require.NoError(t, err)
+		assert.Len(t, items, 1)
+	}
+
+	t.Run("cached addresses are still checked", func(t *testing.T) {
+		config.BlockPrivateNetworks = true
+		config.RequestTimeout = time.Second
+		blocking := NewFeedFetcherWithParser(config, nil)
+		blocking.parser = blocking.newParser()
+
+		for range 2 {
+			_, err := blocking.FetchAndProcess(context.Background(), feedURL)
+			assert.ErrorIs(t, err, ErrPrivateNetwork)
+		}
+	})
+}
+
+func TestFeedFetcher_DNSCacheShared(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), "Cached"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	feedURL := "http://feed.test:" + port + "/feed"
+
+	var lookups atomic.Int32
+	lookup := func(ctx context.Context, host string) ([]netip.Addr, time.Duration, error) {
+		lookups.Add(1)
+		return []netip.Addr{netip.MustParseAddr("127.0.0.1")}, time.Hour, nil
+	}
+
+	config := DefaultConfig
+	config.DNSCacheTTL = time.Minute
+	fetcher := NewFeedFetcherWithParser(config, nil).WithDNSLookup(lookup)
+
+	_, err = fetcher.FetchAndProcess(context.Background(), feedURL)
+	require.NoError(t, err)
+	derived := fetcher.WithUserAgent("derived/1.0")
+	_, err = derived.FetchAndProcess(context.Background(), feedURL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), lookups.Load(), "derived fetchers share the cache")
+	assert.Same(t, fetcher.transport.dnsCache, derived.transport.dnsCache)
+
+	relookup := fetcher.WithDNSLookup(lookup)
+	_, err = relookup.FetchAndProcess(context.Background(), feedURL)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), lookups.Load(), "a new lookup starts a new cache")
+	assert.NotSame(t, fetcher.transport.dnsCache, fetcher.WithResolver(nil).transport.dnsCache,
+		"a new resolver starts a new cache")
+}
+
+func TestRejectPrivateAddress(t *testing.T) {
+	assert.NoError(t, rejectPrivateAddress("tcp", "93.184.216.34:443", nil))
+	assert.NoError(t, rejectPrivateAddress("tcp6", "[2606:2800:220:1:248:1893:25c8:1946]:443", nil))
+	assert.ErrorIs(t, rejectPrivateAddress("tcp6", "[::1]:80", nil), ErrPrivateNetwork)
+	assert.ErrorIs(t, rejectPrivateAddress("tcp6", "[fd00::1]:80", nil), ErrPrivateNetwork)
+	assert.ErrorIs(t, rejectPrivateAddress("tcp", "0.0.0.0:80", nil), ErrPrivateNetwork)
+}
+
+func TestFeedFetcher_MaxRedirects(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /N redirects to /N-1; /0 serves the feed
+		n, _ := strconv.Atoi(r.URL.Path[1:])
+		if n > 0 {
+			http.Redirect(w, r, server.URL+"/"+strconv.Itoa(n-1), http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, rssDocument(time.Now(), "Redirected"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name         string
+		maxRedirects int
+		hops         int
+		wantErr      error
+	}{
+		{"default limit", 0, 10, nil},
+		{"over default limit", 0, 11, ErrTooManyRedirects},
+		{"custom limit", 2, 2, nil},
+		{"over custom limit", 2, 3, ErrTooManyRedirects},
+		{"redirects disabled", -1, 1, ErrTooManyRedirects},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.MaxRedirects = tt.maxRedirects
+			fetcher := NewFeedFetcherWithParser(config, nil)
+			fetcher.parser = fetcher.newParser()
+
+			_, err := fetcher.FetchAndProcess(context.Background(), server.URL+"/"+strconv.Itoa(tt.hops))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestCheckRedirect_Downgrade(t *testing.T) {
+	redirect := func(config Config, from, to string) error {
+		via, err := http.NewRequest(http.MethodGet, from, nil)
+		require.NoError(t, err)
+		req, err := http.NewRequest(http.MethodGet, to, nil)
+		require.NoError(t, err)
+		return checkRedirect(config, nil)(req, []*http.Request{via})
+	}
+
+	config := DefaultConfig
+	assert.NoError(t, redirect(config, "http://example.com/", "https://example.com/"))
+	assert.NoError(t, redirect(config, "https://example.com/", "https://www.example.com/"))
+	assert.ErrorIs(t, redirect(config, "https://example.com/", "http://example.com/"), ErrInsecureRedirect)
+	assert.ErrorIs(t, redirect(config, "http://example.com/", "file:///etc/passwd"), ErrInsecureRedirect)
+
+	config.AllowInsecureRedirect = true
+	assert.NoError(t, redirect(config, "https://example.com/", "http://example.com/"))
+}
+
+// selfSignedClientCert returns a client certificate usable as its own CA.
+func selfSignedClientCert(t *testing.T) (tls.Certificate, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "feedfetcher test client"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, leaf
+}
+
+func TestFeedFetcher_WithClientCertificate(t *testing.T) {
+	cert, leaf := selfSignedClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(leaf)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, rssDocument(time.Now(), r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	fetcher := NewDefaultFeedFetcher().WithTLSConfig(&tls.Config{RootCAs: roots})
+	fetcher.SetDomainRateLimit("127.0.0.1", rate.Inf, 1)
+
+	_, err := fetcher.FetchAndProcess(context.Background(), server.URL)
+	assert.Error(t, err, "rejected without a client certificate")
+
+	items, err := fetcher.WithClientCertificate(cert).FetchAndProcess(context.Background(), server.URL)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "feedfetcher test client", items[0].Headline)
+
+	_, err = fetcher.WithClientCertificate(cert).WithTLSConfig(nil).FetchAndProcess(context.Background(), server.URL)
+	assert.Error(t, err, "default settings do not trust the test server")
+}
@@ -0,0 +1,75 @@
+package feedfetcher
+
+import (
+	"sync/atomic"
+
+	"github.com/reddot-watch/feedfetcher/internal/domain"
+)
+
+// userAgentPool hands out user agents in round-robin order. Fetchers derived
+// from one another share the pool, so rotation continues across copies.
+type userAgentPool struct {
+	agents []string
+	next   atomic.Uint64
+}
+
+func newUserAgentPool(agents []string) *userAgentPool {
+	var pool []string
+	for _, agent := range agents {
+		if agent != "" {
+			pool = append(pool, agent)
+		}
+	}
+	if len(pool) == 0 {
+		return nil
+	}
+	return &userAgentPool{agents: pool}
+}
+
+func (p *userAgentPool) pick() string {
+	n := p.next.Add(1) - 1
+	return p.agents[n%uint64(len(p.agents))]
+}
+
+// WithUserAgentPool returns a new FeedFetcher that rotates through agents,
+// using the next one for each fetch. A UserAgent set in FetchOptions still
+// takes precedence. Passing an empty pool restores the configured UserAgent.
+func (f *FeedFetcher) WithUserAgentPool(agents []string) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.userAgents = newUserAgentPool(agents)
+	return &newFetcher
+}
+
+// WithUserAgentForDomain returns a new FeedFetcher that sends userAgent to
+// feeds on the registrable domain of host and all its subdomains, so
+// "example.com" and "www.example.com" both cover "feeds.example.com". It
+// takes precedence over the user agent pool and the configured UserAgent,
+// but not over a UserAgent set in FetchOptions. An empty userAgent removes
+// the override.
+func (f *FeedFetcher) WithUserAgentForDomain(host, userAgent string) *FeedFetcher {
+	newFetcher := *f
+	newFetcher.domainUAs = make(map[string]string, len(f.domainUAs)+1)
+	for key, agent := range f.domainUAs {
+		newFetcher.domainUAs[key] = agent
+	}
+
+	key := domain.Registrable(host)
+	if userAgent == "" {
+		delete(newFetcher.domainUAs, key)
+	} else {
+		newFetcher.domainUAs[key] = userAgent
+	}
+	return &newFetcher
+}
+
+// userAgentFor returns the user agent to send to host: its domain's
+// override, the next one from the pool, or empty for the configured default.
+func (f *FeedFetcher) userAgentFor(host string) string {
+	if agent, ok := f.domainUAs[domain.Registrable(host)]; ok {
+		return agent
+	}
+	if f.userAgents != nil {
+		return f.userAgents.pick()
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package feedfetcher
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rssWithXMLBase is hosted under /feeds/ but declares its content's location
+// with xml:base, on the channel and again, relative to it, on an item.
+const rssWithXMLBase = `<?xml version="1.0"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+<channel xml:base="https://cdn.example.org/blog/">
+  <title>Blog</title>
+  <item xml:base="2025/">
+    <title>Nested base</title>
+    <link>one.html</link>
+    <pubDate>%[1]s</pubDate>
+    <enclosure url="one.mp3" type="audio/mpeg" length="1"/>
+    <media:content url="/images/one.jpg" medium="image"/>
+  </item>
+  <item>
+    <title>Channel base</title>
+    <link>two.html</link>
+    <pubDate>%[1]s</pubDate>
+  </item>
+</channel>
+</rss>`
+
+func TestFeedFetcher_XMLBase(t *testing.T) {
+	doc := strings.ReplaceAll(rssWithXMLBase, "%[1]s", time.Now().Format(time.RFC1123Z))
+
+	tests := []struct {
+		name          string
+		ignore        bool
+		wantURLs      []string
+		wantEnclosure string
+		wantMedia     string
+	}{
+		{
+			name:          "honored",
+			wantURLs:      []string{"https://cdn.example.org/blog/2025/one.html", "https://cdn.example.org/blog/two.html"},
+			wantEnclosure: "https://cdn.example.org/blog/2025/one.mp3",
+			wantMedia:     "https://cdn.example.org/images/one.jpg",
+		},
+		{
+			name:          "ignored",
+			ignore:        true,
+			wantURLs:      []string{"https://example.com/feeds/one.html", "https://example.com/feeds/two.html"},
+			wantEnclosure: "https://example.com/feeds/one.mp3",
+			wantMedia:     "https://example.com/images/one.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig
+			config.IgnoreXMLBase = tt.ignore
+			items, err := NewFeedFetcher(config).ParseReader("https://example.com/feeds/blog.xml", strings.NewReader(doc))
+			require.NoError(t, err)
+			require.Len(t, items, 2)
+
+			assert.Equal(t, tt.wantURLs, []string{items[0].URL, items[1].URL})
+			assert.Equal(t, "https://example.com/feeds/blog.xml", items[0].FeedURL)
+			assert.Contains(t, items[0].Links, Link{Href: tt.wantEnclosure, Rel: "enclosure", Type: "audio/mpeg"})
+			require.Len(t, items[0].Media, 1)
+			assert.Equal(t, tt.wantMedia, items[0].Media[0].URL)
+		})
+	}
+}
+
+func TestFeedFetcher_XMLBase_Atom(t *testing.T) {
+	doc := `<feed xmlns="http://www.w3.org/2005/Atom" xml:base="https://cdn.example.org/blog/">
+		<title>Blog</title>
+		<entry xml:base="2025/"><title>Entry</title><id>urn:1</id>
+		<updated>` + time.Now().UTC().Format(time.RFC3339) + `</updated><link href="one.html"/></entry>
+		</feed>`
+
+	items, err := NewDefaultFeedFetcher().ParseReader("https://example.com/feeds/blog.xml", strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "https://cdn.example.org/blog/2025/one.html", items[0].URL)
+}
+
+func TestFeedFetcher_XMLBase_ProtocolRelative(t *testing.T) {
+	doc := `<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/" xmlns:podcast="https://podcastindex.org/namespace/1.0">
+<channel xml:base="http://cdn.example.org/"><title>Mixed</title>
+  <item><title>Episode</title><link>//example.com/episode</link>
+    <pubDate>` + time.Now().UTC().Format(time.RFC1123Z) + `</pubDate>
+    <enclosure url="//media.example.org/episode.mp3" type="audio/mpeg" length="1"/>
+    <media:content url="//media.example.org/episode.mp4"/>
+    <podcast:transcript url="//media.example.org/episode.vtt" type="text/vtt"/>
+    <source url="//origin.example.net/feed.xml">Origin</source>
+  </item>
+</channel></rss>`
+
+	items, err := NewDefaultFeedFetcher().ParseReader("https://example.com/feed.xml", strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	item := items[0]
+	assert.Equal(t, "https://example.com/episode", item.URL)
+	require.NotEmpty(t, item.Links)
+	for _, link := range item.Links {
+		assert.True(t, strings.HasPrefix(link.Href, "https://"), link.Href)
+	}
+	require.Len(t, item.Media, 1)
+	assert.Equal(t, "https://media.example.org/episode.mp4", item.Media[0].URL)
+	require.NotNil(t, item.Podcast)
+	assert.Equal(t, "https://media.example.org/episode.vtt", item.Podcast.Transcripts[0].URL)
+	assert.Equal(t, "https://origin.example.net/feed.xml", item.SourceURL)
+}